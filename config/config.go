@@ -13,6 +13,7 @@ var Conf = &NXJConfig{
 	Log:      make(map[string]any),
 	Template: make(map[string]any),
 	Pool:     make(map[string]any),
+	Server:   make(map[string]any),
 }
 
 type NXJConfig struct {
@@ -20,6 +21,8 @@ type NXJConfig struct {
 	Log      map[string]any
 	Template map[string]any
 	Pool     map[string]any
+	// Server http.Server 配置，如 read_timeout = "5s"、max_header_bytes = 1048576
+	Server map[string]any
 }
 
 //func init() {
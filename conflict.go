@@ -0,0 +1,156 @@
+package nxjgo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RouteConflictError 注册路由时发现的冲突
+type RouteConflictError struct {
+	Method        string
+	Path          string // 新注册路由的完整路径
+	Group         string
+	ConflictPath  string // 已注册路由的完整路径，路由本身不合法时为空
+	ConflictGroup string
+	Reason        string
+}
+
+func (e *RouteConflictError) Error() string {
+	if e.ConflictPath == "" {
+		return fmt.Sprintf("invalid route %s %s in group %q: %s", e.Method, e.Path, e.Group, e.Reason)
+	}
+	return fmt.Sprintf("route conflict: %s %s in group %q conflicts with %s in group %q: %s",
+		e.Method, e.Path, e.Group, e.ConflictPath, e.ConflictGroup, e.Reason)
+}
+
+func (r *routerGroup) groupName() string {
+	return strings.TrimPrefix(r.prefix, "/")
+}
+
+// checkConflict 检查新路由是否合法，以及是否与已注册的路由冲突:
+//   - 同一路由组内相同路径和方法重复注册，或与 ANY 重叠
+//   - 同一路由组内结构相同、只有参数名或 :name 与 * 不同的路由，它们匹配完全相同的请求
+//   - 优先匹配的路由组(更长的前缀)中的路由遮蔽了其他路由组的路由
+func (r *routerGroup) checkConflict(name string, method string) error {
+	newErr := func(path string, g *routerGroup, reason string, args ...any) *RouteConflictError {
+		err := &RouteConflictError{
+			Method: method,
+			Path:   r.prefix + name,
+			Group:  r.groupName(),
+			Reason: fmt.Sprintf(reason, args...),
+		}
+		if g != nil {
+			err.ConflictPath = g.prefix + path
+			err.ConflictGroup = g.groupName()
+		}
+		return err
+	}
+	if name != "" && name[0] != '/' {
+		return newErr("", nil, "path must begin with '/'")
+	}
+	sig, err := routeSignature(name)
+	if err != nil {
+		return newErr("", nil, err.Error())
+	}
+
+	for path, methods := range r.handleFuncMap {
+		if path == name {
+			if _, ok := methods[method]; ok {
+				return newErr(path, r, "duplicate route")
+			}
+			if _, ok := methods[ANY]; ok {
+				return newErr(path, r, "ANY is already registered for this path")
+			}
+			if method == ANY && len(methods) > 0 {
+				return newErr(path, r, "ANY would shadow the methods already registered for this path")
+			}
+			continue
+		}
+		if other, _ := routeSignature(path); other == sig {
+			return newErr(path, r, "%s; both routes match exactly the same requests", describeDifference(name, path))
+		}
+	}
+
+	// 其他路由组按匹配顺序排在 r 之前的会先于新路由匹配，排在之后的可能被新路由遮蔽
+	fullPath := r.prefix + name
+	var tree *treeNode
+	before := true
+	for _, g := range r.engine.groups {
+		if g == r {
+			before = false
+			continue
+		}
+		if g.hostPattern().String() != r.hostPattern().String() {
+			continue
+		}
+		if before {
+			if rel, ok := g.match(fullPath); ok {
+				if path, ok := g.treeNode.Get(rel, nil); ok && methodsOverlap(g.handleFuncMap[path], method) {
+					return newErr(path, g, "requests for the new route are matched by group %q first", g.groupName())
+				}
+			}
+			continue
+		}
+		for path, methods := range g.handleFuncMap {
+			rel, ok := r.match(g.prefix + path)
+			if !ok {
+				continue
+			}
+			if tree == nil {
+				tree = &treeNode{}
+				tree.Put(name)
+			}
+			if _, ok := tree.Get(rel, nil); ok && methodsOverlap(methods, method) {
+				return newErr(path, g, "the new route would shadow it because group %q is matched first", r.groupName())
+			}
+		}
+	}
+	return nil
+}
+
+func methodsOverlap(methods map[string]HandlerFunc, method string) bool {
+	if _, ok := methods[ANY]; ok {
+		return true
+	}
+	_, ok := methods[method]
+	return ok || method == ANY && len(methods) > 0
+}
+
+// routeSignature 将 :name 与 * 统一、带约束的参数只保留约束，结构相同的路由签名相同
+func routeSignature(path string) (string, error) {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		switch {
+		case seg == "**":
+			if i != len(segs)-1 {
+				return "", fmt.Errorf("** must be the last segment")
+			}
+		case seg == "*":
+			segs[i] = "\x00"
+		case isWildSegment(seg):
+			_, constraint, err := parseParamSegment(seg)
+			if err != nil {
+				return "", err
+			}
+			segs[i] = "\x00"
+			if constraint != nil {
+				segs[i] += "<" + constraint.expr + ">"
+			}
+		}
+	}
+	return strings.Join(segs, "/"), nil
+}
+
+func describeDifference(a, b string) string {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := range as {
+		if as[i] == bs[i] {
+			continue
+		}
+		if as[i][0] == ':' && bs[i][0] == ':' {
+			return fmt.Sprintf("parameters %q and %q capture the same segment under different names", as[i], bs[i])
+		}
+		return fmt.Sprintf("%q and %q capture the same segment", as[i], bs[i])
+	}
+	return "routes are identical"
+}
@@ -0,0 +1,69 @@
+package nxjgo
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRouteConflicts(t *testing.T) {
+	h := func(ctx *Context) {}
+	e := New()
+	user := e.Group("user")
+	user.Get("/:id", h)
+	user.Get("/new", h)
+	user.Get("/:id<int>/orders", h)
+	user.Any("/any", h)
+	user.Get("/:name/profile", h)
+	root := e.Group("")
+	root.Get("/files/**", h)
+
+	tests := []struct {
+		g        *routerGroup
+		method   string
+		path     string
+		conflict string
+		reason   string
+	}{
+		{user, http.MethodGet, "/:id", "/user/:id", "duplicate route"},
+		{user, http.MethodPost, "/any", "/user/any", "ANY is already registered"},
+		{user, ANY, "/new", "/user/new", "ANY would shadow"},
+		{user, http.MethodPost, "/:name", "/user/:id", `parameters ":name" and ":id"`},
+		{user, http.MethodGet, "/*", "/user/:id", `"*" and ":id"`},
+		{user, http.MethodGet, "/:uid<int>/orders", "/user/:id<int>/orders", "different names"},
+		{user, http.MethodGet, "/:id/profile", "/user/:name/profile", "different names"},
+		{user, http.MethodGet, "/files/**/edit", "", "** must be the last segment"},
+		{user, http.MethodGet, "/:id<[0-9+>", "", "invalid route parameter constraint"},
+		{root, http.MethodGet, "/user/list", "/user/:id", `matched by group "user" first`},
+		{e.Group("files"), http.MethodGet, "/*", "/files/**", `group "files" is matched first`},
+	}
+	for _, tt := range tests {
+		_, err := tt.g.TryHandle(tt.method, tt.path, h)
+		var conflict *RouteConflictError
+		if !errors.As(err, &conflict) {
+			t.Errorf("TryHandle(%s %s) error = %v, want RouteConflictError", tt.method, tt.path, err)
+			continue
+		}
+		if conflict.ConflictPath != tt.conflict || !strings.Contains(conflict.Reason, tt.reason) {
+			t.Errorf("TryHandle(%s %s) = %v, want conflict with %q containing %q", tt.method, tt.path, err, tt.conflict, tt.reason)
+		}
+	}
+
+	// 不冲突的路由
+	for _, path := range []string{"/:id<uuid>", "/new/:id", "/:id/orders"} {
+		if _, err := user.TryHandle(http.MethodGet, path, h); err != nil {
+			t.Errorf("TryHandle(GET %s) = %v, want nil", path, err)
+		}
+	}
+	if _, err := user.TryHandle(http.MethodPost, "/:id", h); err != nil {
+		t.Errorf("TryHandle(POST /:id) = %v, want nil", err)
+	}
+
+	defer func() {
+		if _, ok := recover().(*RouteConflictError); !ok {
+			t.Errorf("Get duplicate route did not panic with RouteConflictError")
+		}
+	}()
+	user.Get("/new", h)
+}
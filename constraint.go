@@ -0,0 +1,118 @@
+package nxjgo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// paramConstraint 路由参数约束，如 /user/:id<int>、/file/:name<[a-z0-9-]+>
+type paramConstraint struct {
+	expr  string
+	match func(seg string) bool
+}
+
+// 内置约束，其余表达式按正则处理并要求整段匹配
+var builtinConstraints = map[string]func(seg string) bool{
+	"int":   isInt,
+	"uint":  isDigits,
+	"alpha": isAlpha,
+	"uuid":  isUUID,
+	"date":  isDate,
+}
+
+// parseParamSegment 解析 :name 或 :name<constraint>
+func parseParamSegment(seg string) (string, *paramConstraint, error) {
+	key := seg[1:]
+	i := strings.IndexByte(key, '<')
+	if i < 0 {
+		return key, nil, nil
+	}
+	if i == 0 || key[len(key)-1] != '>' || len(key)-i < 3 {
+		return "", nil, fmt.Errorf("invalid route parameter %q", seg)
+	}
+	expr := key[i+1 : len(key)-1]
+	key = key[:i]
+	if match, ok := builtinConstraints[expr]; ok {
+		return key, &paramConstraint{expr: expr, match: match}, nil
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid route parameter constraint %q: %w", seg, err)
+	}
+	return key, &paramConstraint{expr: expr, match: re.MatchString}, nil
+}
+
+func mustParseParamSegment(seg string) (string, *paramConstraint) {
+	key, constraint, err := parseParamSegment(seg)
+	if err != nil {
+		panic(err)
+	}
+	return key, constraint
+}
+
+func isInt(s string) bool {
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	return isDigits(s)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlpha(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := toLowerASCII(s[i]); c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	c = toLowerASCII(c)
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f'
+}
+
+// isUUID 8-4-4-4-12 格式的 UUID
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isDate 2006-01-02 格式的日期
+func isDate(s string) bool {
+	if len(s) != len(time.DateOnly) || s[4] != '-' || s[7] != '-' {
+		return false
+	}
+	_, err := time.Parse(time.DateOnly, s)
+	return err == nil
+}
@@ -1,7 +1,9 @@
 package nxjgo
 
 import (
+	"crypto/x509"
 	"errors"
+	"fmt"
 	"github.com/Komorebi695/nxjgo/binding"
 	nxjLog "github.com/Komorebi695/nxjgo/log"
 	"github.com/Komorebi695/nxjgo/render"
@@ -11,9 +13,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 )
 
 const defaultMultipartMemory = 32 << 20 // 32 MB
@@ -35,6 +39,133 @@ type Context struct {
 	Keys       map[string]any
 	mu         sync.RWMutex
 	sameSite   http.SameSite
+	// Params 当前请求匹配到的路由参数，按路由中出现的顺序排列
+	Params  Params
+	group   *routerGroup
+	route   string
+	writer  responseWriter
+	aborted bool
+	nexts   []nextFrame
+}
+
+// nextFrame HandlerMiddleware 中 ctx.Next() 要执行的后续处理函数
+type nextFrame struct {
+	next   HandlerFunc
+	called bool
+}
+
+// reset 清理上一次请求遗留的状态，Context 从 Engine.pool 复用前调用
+func (c *Context) reset() {
+	c.queryCache = nil
+	c.formCache = nil
+	c.StatusCode = 0
+	c.Keys = nil
+	c.Params = c.Params[:0]
+	c.group = nil
+	c.route = ""
+	c.aborted = false
+	c.nexts = c.nexts[:0]
+}
+
+// Next 在 HandlerMiddleware 注册的中间件中执行后续的中间件和处理函数，只执行一次
+func (c *Context) Next() {
+	i := len(c.nexts) - 1
+	if i < 0 || c.nexts[i].called {
+		return
+	}
+	c.nexts[i].called = true
+	next := c.nexts[i].next
+	if !c.aborted {
+		next(c)
+	}
+}
+
+// Abort 阻止执行后续的中间件和处理函数，不影响当前函数中剩余的代码
+func (c *Context) Abort() {
+	c.aborted = true
+}
+
+// IsAborted 是否已调用 Abort
+func (c *Context) IsAborted() bool {
+	return c.aborted
+}
+
+// AbortWithStatus 设置状态码并 Abort
+func (c *Context) AbortWithStatus(code int) {
+	c.Abort()
+	c.W.WriteHeader(code)
+	c.StatusCode = code
+}
+
+// AbortWithStatusJSON 以 JSON 响应并 Abort
+func (c *Context) AbortWithStatusJSON(code int, data any) error {
+	c.Abort()
+	return c.JSON(code, data)
+}
+
+// FullPath 返回匹配到的完整路由模板(含路由组)，如 /user/get/:id，未匹配时为空串
+func (c *Context) FullPath() string {
+	if c.group == nil {
+		return ""
+	}
+	return c.group.prefix + c.route
+}
+
+// Param 返回路由参数值，如 /user/:id 中的 id；* 与 ** 分别以 "*"、"**" 为名
+func (c *Context) Param(key string) string {
+	return c.Params.ByName(key)
+}
+
+// ParamInt 返回 int 类型的路由参数，通常配合 :id<int> 约束使用
+func (c *Context) ParamInt(key string) (int, error) {
+	return strconv.Atoi(c.Param(key))
+}
+
+// ParamInt64 返回 int64 类型的路由参数
+func (c *Context) ParamInt64(key string) (int64, error) {
+	return strconv.ParseInt(c.Param(key), 10, 64)
+}
+
+// ParamUint64 返回 uint64 类型的路由参数，通常配合 :id<uint> 约束使用
+func (c *Context) ParamUint64(key string) (uint64, error) {
+	return strconv.ParseUint(c.Param(key), 10, 64)
+}
+
+// ParamUUID 返回小写形式的 UUID 路由参数，通常配合 :id<uuid> 约束使用
+func (c *Context) ParamUUID(key string) (string, error) {
+	v := c.Param(key)
+	if !isUUID(v) {
+		return "", fmt.Errorf("route param %s: invalid uuid %q", key, v)
+	}
+	return strings.ToLower(v), nil
+}
+
+// ParamDate 返回 2006-01-02 格式的日期路由参数，通常配合 :date<date> 约束使用
+func (c *Context) ParamDate(key string) (time.Time, error) {
+	return time.Parse(time.DateOnly, c.Param(key))
+}
+
+// ClientCert 返回经过校验的客户端证书，未开启双向认证或客户端未提供证书时返回 nil
+func (c *Context) ClientCert() *x509.Certificate {
+	if c.R.TLS == nil || len(c.R.TLS.VerifiedChains) == 0 || len(c.R.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return c.R.TLS.VerifiedChains[0][0]
+}
+
+// ClientIdentity 返回客户端证书标识的身份: 优先使用第一个 URI SAN(如 SPIFFE ID)，其次 DNS SAN，最后 CommonName
+func (c *Context) ClientIdentity() string {
+	cert := c.ClientCert()
+	switch {
+	case cert == nil:
+		return ""
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	default:
+		return cert.Subject.CommonName
+	}
 }
 
 func (c *Context) GetCookie(name string) (string, error) {
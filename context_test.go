@@ -0,0 +1,107 @@
+package nxjgo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAbortAndNext(t *testing.T) {
+	var order []string
+	e := New()
+	// 装饰器风格
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			order = append(order, "auth")
+			if ctx.R.Header.Get("Token") == "" {
+				_ = ctx.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			}
+			// Abort 之后即使继续调用 next 也不会执行后续处理函数
+			next(ctx)
+		}
+	})
+	// 处理函数列表风格，后注册的在外层
+	e.UseHandler(func(ctx *Context) {
+		order = append(order, "timing:before")
+		ctx.Next()
+		order = append(order, "timing:after")
+		// 响应体未写出时仍可修改状态码
+		if ctx.StatusCode == http.StatusNoContent {
+			ctx.W.WriteHeader(http.StatusAccepted)
+		}
+	})
+	g := e.Group("")
+	g.UseHandler(func(ctx *Context) {
+		order = append(order, "group")
+	})
+	g.Get("/ping", func(ctx *Context) {
+		order = append(order, "handler")
+		ctx.W.WriteHeader(http.StatusNoContent)
+	})
+
+	w := performRequest(e, http.MethodGet, "/ping")
+	if w.Code != http.StatusUnauthorized || w.Body.String() != `{"error":"unauthorized"}` {
+		t.Errorf("aborted request = %d %q", w.Code, w.Body.String())
+	}
+	if want := []string{"timing:before", "auth", "timing:after"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("aborted order = %v, want %v", order, want)
+	}
+
+	order = nil
+	req := newRequest(http.MethodGet, "/ping")
+	req.Header.Set("Token", "1")
+	w = serve(e, req)
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	if want := []string{"timing:before", "auth", "group", "handler", "timing:after"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestAbortWithStatus(t *testing.T) {
+	e := New()
+	g := e.Group("")
+	g.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			ctx.AbortWithStatus(http.StatusForbidden)
+			next(ctx)
+		}
+	})
+	g.Get("/", func(ctx *Context) { _ = ctx.String(http.StatusOK, "ok") })
+
+	w := performRequest(e, http.MethodGet, "/")
+	if w.Code != http.StatusForbidden || w.Body.Len() != 0 {
+		t.Errorf("AbortWithStatus = %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestResponseWriterReadFrom(t *testing.T) {
+	e := New()
+	var size int
+	e.Group("").Get("/file", func(ctx *Context) {
+		rf, ok := ctx.W.(io.ReaderFrom)
+		if !ok {
+			t.Error("ctx.W does not implement io.ReaderFrom")
+			return
+		}
+		ctx.W.WriteHeader(http.StatusAccepted)
+		_, _ = rf.ReadFrom(strings.NewReader("hello"))
+		size = ctx.W.(*responseWriter).Size()
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusAccepted || string(body) != "hello" || size != len("hello") {
+		t.Errorf("ReadFrom = %d %q, size %d", resp.StatusCode, body, size)
+	}
+}
@@ -0,0 +1,84 @@
+package nxjgo
+
+import (
+	"context"
+	"golang.org/x/net/http/httpguts"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// RunH2C 以 HTTP/2 明文(h2c)监听 addr 并阻塞，支持 prior knowledge 和 HTTP/1.1 Upgrade 两种方式，
+// 普通 HTTP/1.1 请求照常处理。收到 SIGINT、SIGTERM 或调用 Shutdown 后优雅退出
+func (e *Engine) RunH2C(addr string) error {
+	ctx, stop := signalContext()
+	defer stop()
+	if addr == "" {
+		addr = ":http"
+	}
+	l, err := e.listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := e.newServer(addr)
+	e.enableH2C(srv)
+	return e.serve(ctx, endpoint{srv: srv, l: l})
+}
+
+// enableH2C 为 srv 开启 h2c。h2c 连接被劫持后不再由 http.Server 跟踪，
+// 通过 http2.ConfigureServer 在 Shutdown 时发送 GOAWAY，并记录活跃连接数供 Shutdown 等待
+func (e *Engine) enableH2C(srv *http.Server) {
+	if srv.Handler != e {
+		// 已开启
+		return
+	}
+	h2s := &http2.Server{IdleTimeout: srv.IdleTimeout}
+	_ = http2.ConfigureServer(srv, h2s)
+	h := h2c.NewHandler(http.HandlerFunc(serveH2CUpgrade(e)), h2s)
+	srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 || isH2CUpgrade(r.Header) {
+			// 该调用持续到 h2c 连接关闭
+			e.h2cConns.Add(1)
+			defer e.h2cConns.Add(-1)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// serveH2CUpgrade 通过 Upgrade 升级的首个请求仍是原始的 HTTP/1.1 请求，修正为 HTTP/2 后再处理
+func serveH2CUpgrade(h http.Handler) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 1 && isH2CUpgrade(r.Header) {
+			r2 := new(http.Request)
+			*r2 = *r
+			r2.Proto, r2.ProtoMajor, r2.ProtoMinor = "HTTP/2.0", 2, 0
+			r2.Header = r.Header.Clone()
+			for _, key := range []string{"Connection", "Upgrade", "Http2-Settings"} {
+				r2.Header.Del(key)
+			}
+			r = r2
+		}
+		h.ServeHTTP(w, r)
+	}
+}
+
+func isH2CUpgrade(h http.Header) bool {
+	return httpguts.HeaderValuesContainsToken(h["Upgrade"], "h2c") &&
+		httpguts.HeaderValuesContainsToken(h["Connection"], "HTTP2-Settings")
+}
+
+// waitH2C 等待 h2c 连接在收到 GOAWAY 后处理完剩余请求，ctx 到期时不再等待
+func waitH2C(ctx context.Context, conns *atomic.Int64) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for conns.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
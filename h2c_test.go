@@ -0,0 +1,127 @@
+package nxjgo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRunH2C(t *testing.T) {
+	addr := freeAddr(t)
+	var logs bytes.Buffer
+	e := New()
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return LoggerWithConfig(LoggingConfig{out: &logs}, next)
+	})
+	e.Group("").Get("/proto", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "%s %d %s", ctx.R.Proto, ctx.R.ProtoMajor, ctx.R.Header.Get("Upgrade"))
+	})
+	runErr := make(chan error, 1)
+	go func() { runErr <- e.RunH2C(addr) }()
+	waitServing(t, "http://"+addr+"/proto")
+
+	// HTTP/1.1 照常处理
+	h1 := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := h1.Get("http://" + addr + "/proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(b) != "HTTP/1.1 1 " {
+		t.Errorf("HTTP/1.1 body = %q", b)
+	}
+
+	// prior knowledge
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+	resp, err = client.Get("http://" + addr + "/proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 || string(b) != "HTTP/2.0 2 " {
+		t.Errorf("prior knowledge: response proto %d, body = %q", resp.ProtoMajor, b)
+	}
+	client.CloseIdleConnections()
+
+	// Upgrade
+	if body := h2cUpgrade(t, addr, "/proto"); body != "HTTP/2.0 2 " {
+		t.Errorf("upgrade body = %q", body)
+	}
+	if strings.Count(logs.String(), "HTTP/2.0") != 2 {
+		t.Errorf("log does not report HTTP/2.0:\n%s", logs.String())
+	}
+
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("RunH2C = %v", err)
+	}
+}
+
+// h2cUpgrade 发送带 Upgrade: h2c 的 HTTP/1.1 请求，在升级后的连接上读取 stream 1 的响应体
+func h2cUpgrade(t *testing.T, addr, path string) string {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, _ = io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: "+addr+
+		"\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABk\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("upgrade status = %d", resp.StatusCode)
+	}
+	_, _ = io.WriteString(conn, http2.ClientPreface)
+	framer := http2.NewFramer(conn, br)
+	if err := framer.WriteSettings(); err != nil {
+		t.Fatal(err)
+	}
+	var body bytes.Buffer
+	for {
+		f, err := framer.ReadFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch f := f.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				_ = framer.WriteSettingsAck()
+			}
+		case *http2.HeadersFrame:
+			fields, _ := hpack.NewDecoder(4096, nil).DecodeFull(f.HeaderBlockFragment())
+			for _, hf := range fields {
+				if hf.Name == ":status" && hf.Value != "200" {
+					t.Fatalf("upgrade stream status = %s", hf.Value)
+				}
+			}
+		case *http2.DataFrame:
+			if f.StreamID == 1 {
+				body.Write(f.Data())
+				if f.StreamEnded() {
+					return body.String()
+				}
+			}
+		}
+	}
+}
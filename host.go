@@ -0,0 +1,79 @@
+package nxjgo
+
+import (
+	"net/http"
+	"strings"
+)
+
+// hostPattern 路由组的 Host 匹配规则，按 . 分级，{name} 匹配任意一级
+type hostPattern struct {
+	pattern string
+	labels  []string
+	params  int
+}
+
+func newHostPattern(pattern string) *hostPattern {
+	h := &hostPattern{pattern: pattern, labels: strings.Split(pattern, ".")}
+	for _, label := range h.labels {
+		if label == "" {
+			panic("invalid host pattern: " + pattern)
+		}
+		if label[0] == '{' {
+			if len(label) < 3 || label[len(label)-1] != '}' {
+				panic("invalid host pattern: " + pattern)
+			}
+			h.params++
+		}
+	}
+	return h
+}
+
+func (h *hostPattern) String() string {
+	if h == nil {
+		return ""
+	}
+	return h.pattern
+}
+
+// match 匹配 Host(不含端口)，匹配到的 {name} 追加到 params 中
+func (h *hostPattern) match(host string, params *Params) bool {
+	start := 0
+	if params != nil {
+		start = len(*params)
+	}
+	for i, label := range h.labels {
+		end := strings.IndexByte(host, '.')
+		if i == len(h.labels)-1 {
+			end = len(host)
+		}
+		if end <= 0 || strings.IndexByte(host[:end], '.') >= 0 {
+			return h.fail(params, start)
+		}
+		if label[0] == '{' {
+			pushParam(params, label[1:len(label)-1], host[:end])
+		} else if !equalFoldASCII(host[:end], label) {
+			return h.fail(params, start)
+		}
+		host = host[end:]
+		if i < len(h.labels)-1 {
+			host = host[1:]
+		}
+	}
+	return true
+}
+
+func (h *hostPattern) fail(params *Params, start int) bool {
+	if params != nil {
+		*params = (*params)[:start]
+	}
+	return false
+}
+
+// requestHost 返回去掉端口的 Host
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && strings.IndexByte(host[i:], ']') < 0 {
+		host = host[:i]
+	}
+	return strings.TrimSuffix(host, ".")
+}
@@ -0,0 +1,197 @@
+package nxjgo
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+)
+
+const (
+	// EnvListenFds 继承的监听套接字数量，套接字从文件描述符 3 开始依次排列
+	EnvListenFds = "LISTEN_FDS"
+	// EnvListenPid 接收套接字的进程号，设置时与当前进程不一致则忽略 LISTEN_FDS
+	EnvListenPid = "LISTEN_PID"
+
+	listenFdsStart = 3
+)
+
+// RunListener 在 l 上服务并阻塞，收到 SIGINT、SIGTERM 或调用 Shutdown 后优雅退出
+func (e *Engine) RunListener(l net.Listener) error {
+	ctx, stop := signalContext()
+	defer stop()
+	return e.serveListeners(ctx, l)
+}
+
+// RunUnix 监听 Unix 域套接字 path 并设置文件权限为 perm，退出时删除套接字文件。
+// path 上残留的旧套接字文件会被删除，其他类型的文件则返回错误。
+// perm 在 bind 之后才设置，此前套接字文件的权限由进程 umask 决定，期间可能接受到其他用户的连接，
+// 因此 perm 不能作为安全边界，需要限制访问时应将 path 放在只有允许的用户可以进入的目录中
+func (e *Engine) RunUnix(path string, perm fs.FileMode) error {
+	l, err := e.takeInherited("unix", path)
+	if err != nil {
+		return err
+	}
+	if l != nil {
+		debugPrint("Using inherited socket unix %s", l.Addr())
+		return e.RunListener(l)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return fmt.Errorf("nxjgo: %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	l, err = net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		_ = l.Close()
+		return err
+	}
+	return e.RunListener(l)
+}
+
+// RunFd 在已打开的监听套接字文件描述符 fd 上服务。
+// RunFd 接管 fd 并负责关闭，调用方不能再使用或关闭它，需要保留时先 dup 一份
+func (e *Engine) RunFd(fd int) error {
+	l, err := fdListener(fd)
+	if err != nil {
+		return err
+	}
+	return e.RunListener(l)
+}
+
+// InheritedListeners 返回通过 LISTEN_FDS 继承的监听套接字，未继承时返回 nil。
+// 读取后清除 LISTEN_FDS、LISTEN_PID，避免子进程重复使用
+func InheritedListeners() ([]net.Listener, error) {
+	fds := os.Getenv(EnvListenFds)
+	if fds == "" {
+		return nil, nil
+	}
+	if pid := os.Getenv(EnvListenPid); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("nxjgo: invalid %s=%q", EnvListenFds, fds)
+	}
+	_ = os.Unsetenv(EnvListenFds)
+	_ = os.Unsetenv(EnvListenPid)
+	listeners := make([]net.Listener, 0, n)
+	for fd := listenFdsStart; fd < listenFdsStart+n; fd++ {
+		l, err := fdListener(fd)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// fdListener 接管 fd，返回的监听器使用复制的文件描述符，fd 本身在返回前关闭
+func fdListener(fd int) (net.Listener, error) {
+	f := os.NewFile(uintptr(fd), "listener-"+strconv.Itoa(fd))
+	if f == nil {
+		return nil, fmt.Errorf("nxjgo: invalid file descriptor %d", fd)
+	}
+	// net.FileListener 复制了文件描述符，原文件可以关闭
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("nxjgo: file descriptor %d: %w", fd, err)
+	}
+	return l, nil
+}
+
+// serveListeners 用同一个 http.Server 在多个监听器上服务，共用优雅退出流程
+func (e *Engine) serveListeners(ctx context.Context, listeners ...net.Listener) error {
+	srv := e.newServer("")
+	endpoints := make([]endpoint, len(listeners))
+	for i, l := range listeners {
+		debugPrint("Listening on %s %s", l.Addr().Network(), l.Addr())
+		endpoints[i] = endpoint{srv: srv, l: l}
+	}
+	return e.serve(ctx, endpoints...)
+}
+
+// inheritedListeners 返回尚未使用的全部继承套接字
+func (e *Engine) inheritedListeners() ([]net.Listener, error) {
+	e.inheritOnce.Do(func() {
+		e.inherited, e.inheritErr = InheritedListeners()
+	})
+	e.srvMu.Lock()
+	defer e.srvMu.Unlock()
+	listeners := e.inherited
+	e.inherited = nil
+	return listeners, e.inheritErr
+}
+
+// takeInherited 返回继承的、监听地址与 network、addr 相同的套接字，没有时返回 nil
+func (e *Engine) takeInherited(network, addr string) (net.Listener, error) {
+	e.inheritOnce.Do(func() {
+		e.inherited, e.inheritErr = InheritedListeners()
+	})
+	e.srvMu.Lock()
+	defer e.srvMu.Unlock()
+	for i, l := range e.inherited {
+		if sameAddr(l.Addr(), network, addr) {
+			e.inherited = append(e.inherited[:i], e.inherited[i+1:]...)
+			return l, nil
+		}
+	}
+	return nil, e.inheritErr
+}
+
+// listen 优先使用继承的同地址套接字，否则新建监听
+func (e *Engine) listen(network, addr string) (net.Listener, error) {
+	l, err := e.takeInherited(network, addr)
+	if err != nil || l != nil {
+		if l != nil {
+			debugPrint("Using inherited socket %s %s", l.Addr().Network(), l.Addr())
+		}
+		return l, err
+	}
+	return net.Listen(network, addr)
+}
+
+// closeInherited 关闭已读取但没有被任何监听使用的继承套接字，避免泄漏文件描述符
+func (e *Engine) closeInherited() {
+	e.srvMu.Lock()
+	listeners := e.inherited
+	e.inherited = nil
+	e.srvMu.Unlock()
+	for _, l := range listeners {
+		debugPrint("Closing unused inherited socket %s %s", l.Addr().Network(), l.Addr())
+		_ = l.Close()
+	}
+}
+
+// sameAddr 判断监听地址 la 是否为 addr，addr 未指定 IP 时与监听所有地址的套接字相同
+func sameAddr(la net.Addr, network, addr string) bool {
+	switch a := la.(type) {
+	case *net.UnixAddr:
+		return network == "unix" && a.Name == addr
+	case *net.TCPAddr:
+		if network != "tcp" {
+			return false
+		}
+		want, err := net.ResolveTCPAddr("tcp", addr)
+		if err != nil || want.Port != a.Port {
+			return false
+		}
+		if len(want.IP) == 0 || want.IP.IsUnspecified() {
+			return a.IP.IsUnspecified()
+		}
+		return a.IP.Equal(want.IP)
+	}
+	return false
+}
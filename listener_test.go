@@ -0,0 +1,160 @@
+package nxjgo
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRunListener(t *testing.T) {
+	e := New()
+	e.Group("").Get("/ping", func(ctx *Context) { _ = ctx.String(http.StatusOK, "pong") })
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runErr := make(chan error, 1)
+	go func() { runErr <- e.RunListener(l) }()
+	waitServing(t, "http://"+l.Addr().String()+"/ping")
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("RunListener = %v", err)
+	}
+}
+
+func TestRunUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nxj.sock")
+	// 残留的旧套接字文件
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip("unix sockets not supported:", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	e := New()
+	e.Group("").Get("/ping", func(ctx *Context) { _ = ctx.String(http.StatusOK, "pong") })
+	runErr := make(chan error, 1)
+	go func() { runErr <- e.RunUnix(path, 0o660) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	var body string
+	for i := 0; i < 100; i++ {
+		if resp, err := client.Get("http://unix/ping"); err == nil {
+			b, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			body = string(b)
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if body != "pong" {
+		t.Fatalf("body = %q, want %q", body, "pong")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o660 || info.Mode()&fs.ModeSocket == 0 {
+		t.Errorf("socket mode = %v", info.Mode())
+	}
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("RunUnix = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file not removed: %v", err)
+	}
+
+	regular := filepath.Join(t.TempDir(), "file")
+	_ = os.WriteFile(regular, nil, 0o644)
+	if err := New().RunUnix(regular, 0o660); err == nil {
+		t.Error("RunUnix replaced a regular file")
+	}
+}
+
+func TestInheritedListeners(t *testing.T) {
+	t.Setenv(EnvListenFds, "")
+	if ls, err := InheritedListeners(); ls != nil || err != nil {
+		t.Errorf("without LISTEN_FDS = %v, %v", ls, err)
+	}
+
+	t.Setenv(EnvListenFds, "1")
+	t.Setenv(EnvListenPid, strconv.Itoa(os.Getpid()+1))
+	if ls, err := InheritedListeners(); ls != nil || err != nil {
+		t.Errorf("LISTEN_PID of another process = %v, %v", ls, err)
+	}
+
+	t.Setenv(EnvListenFds, "x")
+	t.Setenv(EnvListenPid, strconv.Itoa(os.Getpid()))
+	if _, err := InheritedListeners(); err == nil {
+		t.Error("invalid LISTEN_FDS accepted")
+	}
+}
+
+// TestSocketActivation 以子进程运行自身，通过 ExtraFiles 传入监听套接字模拟 socket activation
+func TestSocketActivation(t *testing.T) {
+	if os.Getenv("NXJ_TEST_ACTIVATION") == "1" {
+		e := New()
+		e.Group("").Get("/ping", func(ctx *Context) { _ = ctx.String(http.StatusOK, "activated") })
+		e.Group("").Get("/stop", func(ctx *Context) {
+			go e.Shutdown(context.Background())
+		})
+		if err := e.RunWithContext(context.Background(), "127.0.0.1:1"); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Skip("listener file descriptors not supported:", err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSocketActivation$")
+	cmd.Env = append(os.Environ(), "NXJ_TEST_ACTIVATION=1", EnvListenFds+"=1")
+	cmd.ExtraFiles = []*os.File{f}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	waitServing(t, "http://"+addr+"/ping")
+	resp, err := http.Get("http://" + addr + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(b) != "activated" {
+		t.Errorf("body = %q, want %q", b, "activated")
+	}
+	if resp, err := http.Get("http://" + addr + "/stop"); err == nil {
+		resp.Body.Close()
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("child exited with %v", err)
+	}
+}
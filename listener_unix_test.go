@@ -0,0 +1,41 @@
+//go:build !windows
+
+package nxjgo
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestRunFd(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RunFd 接管并关闭 fd，传入一个不属于任何 *os.File 的副本，避免重复关闭
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = f.Close()
+	_ = l.Close()
+
+	e := New()
+	e.Group("").Get("/ping", func(ctx *Context) {})
+	runErr := make(chan error, 1)
+	go func() { runErr <- e.RunFd(fd) }()
+	waitServing(t, "http://"+addr+"/ping")
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("RunFd = %v", err)
+	}
+}
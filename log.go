@@ -46,6 +46,7 @@ type LogFormatterParams struct {
 	ClientIP       net.IP
 	Method         string
 	Path           string
+	Proto          string
 	IsDisplayColor bool
 }
 
@@ -102,6 +103,7 @@ func LoggerWithConfig(conf LoggingConfig, next HandlerFunc) HandlerFunc {
 			ClientIP:       clientIP,
 			Method:         method,
 			Path:           path,
+			Proto:          ctx.R.Proto,
 			StatusCode:     statusCode,
 			IsDisplayColor: displayColor,
 		}
@@ -117,17 +119,18 @@ var defaultLogFormatter = func(params LogFormatterParams) string {
 	statusCodeColor := params.StatusCodeColor()
 	resetColor := params.ResetColor()
 	if params.IsDisplayColor {
-		return fmt.Sprintf("%s[nxjgo]%s %s%v%s | %s %3d %s |%s %13v %s| %15s  |%s %-7s %s %s %#v %s\n",
+		return fmt.Sprintf("%s[nxjgo]%s %s%v%s | %s %3d %s |%s %13v %s| %15s  |%s %-7s %s %s %#v %s %s\n",
 			yellow, resetColor, blue, params.TimeStamp.Format("2006/01/02 - 15:04:05"), resetColor,
 			statusCodeColor, params.StatusCode, resetColor,
 			red, params.Latency, resetColor,
 			params.ClientIP,
 			magenta, params.Method, resetColor,
 			cyan, params.Path, resetColor,
+			params.Proto,
 		)
 	}
-	return fmt.Sprintf("[nxjgo] %v | %3d | %13v | %15s |%-7s %#v\n",
+	return fmt.Sprintf("[nxjgo] %v | %3d | %13v | %15s |%-7s %#v %s\n",
 		params.TimeStamp.Format("2006/01/02 - 15:04:05"),
 		params.StatusCode,
-		params.Latency, params.ClientIP, params.Method, params.Path)
+		params.Latency, params.ClientIP, params.Method, params.Path, params.Proto)
 }
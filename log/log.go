@@ -218,18 +218,19 @@ func (l *Logger) SetLogPath(logPath string) {
 	l.Outs = append(l.Outs, &LoggerWriter{Level: LevelError, Out: logError})
 }
 
+// CloseWriter 关闭日志文件，标准输出和标准错误不会被关闭
 func (l *Logger) CloseWriter() {
 	for _, out := range l.Outs {
-		file := out.Out.(*os.File)
-		if file != nil {
+		file, ok := out.Out.(*os.File)
+		if ok && file != nil && file != os.Stdout && file != os.Stderr {
 			_ = file.Close()
 		}
 	}
 }
 
 func (l *Logger) CheckFileSize(out *LoggerWriter) {
-	osFile := out.Out.(*os.File)
-	if osFile != nil {
+	osFile, ok := out.Out.(*os.File)
+	if ok && osFile != nil {
 		stat, err := osFile.Stat()
 		if err != nil {
 			log.Println("logger checkFileSize error info:", err)
@@ -0,0 +1,68 @@
+package nxjgo
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// EnvNxjMode 通过环境变量设置运行模式
+const EnvNxjMode = "NXJ_MODE"
+
+const (
+	DebugMode   = "debug"
+	ReleaseMode = "release"
+	TestMode    = "test"
+)
+
+var nxjMode = DebugMode
+
+func init() {
+	SetMode(os.Getenv(EnvNxjMode))
+}
+
+// SetMode 设置运行模式，为空时使用 DebugMode
+func SetMode(value string) {
+	switch value {
+	case "":
+		nxjMode = DebugMode
+	case DebugMode, ReleaseMode, TestMode:
+		nxjMode = value
+	default:
+		panic("nxjgo mode unknown: " + value + " (available mode: debug release test)")
+	}
+}
+
+// Mode 返回当前运行模式
+func Mode() string {
+	return nxjMode
+}
+
+// IsDebugging 是否为 debug 模式
+func IsDebugging() bool {
+	return nxjMode == DebugMode
+}
+
+// DevFS debug 模式下 dir 存在时返回磁盘目录 dir，否则返回 fsys。
+// 开发时修改文件无需重新编译即可生效，发布时使用 embed.FS 打包进二进制
+func DevFS(fsys fs.FS, dir string) fs.FS {
+	if !IsDebugging() {
+		return fsys
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fsys
+	}
+	debugPrint("serving %s from disk instead of the embedded FS", dir)
+	return os.DirFS(dir)
+}
+
+func debugPrint(format string, values ...any) {
+	if !IsDebugging() {
+		return
+	}
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+	fmt.Fprintf(DefaultWriter, "[nxjgo-debug] "+format, values...)
+}
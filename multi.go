@@ -0,0 +1,124 @@
+package nxjgo
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ListenConfig RunMulti 的单个监听配置
+type ListenConfig struct {
+	// Addr 监听地址，Listener 不为空时忽略
+	Addr string
+	// Listener 已打开的监听器，如 InheritedListeners 的返回值；进程继承了同地址的套接字时关闭继承的套接字
+	Listener net.Listener
+	// TLS 使用 Engine.TLS 配置提供 HTTPS
+	TLS bool
+	// H2C 开启 HTTP/2 明文支持
+	H2C bool
+	// RedirectHTTPS 除 RedirectExempt 外的请求均以 308 重定向到 HTTPS
+	RedirectHTTPS bool
+	// RedirectPort 重定向的目标端口，为空时使用同一次 RunMulti 中第一个 TLS 监听的端口，443 时省略
+	RedirectPort string
+	// RedirectExempt 不重定向、照常处理的路径，如健康检查 /healthz；以 / 结尾时按前缀匹配
+	RedirectExempt []string
+}
+
+// RunMulti 在多个监听上同时服务并阻塞，收到 SIGINT、SIGTERM 或调用 Shutdown 后全部一起优雅退出，
+// 任意一个监听失败时其余监听也随之退出
+func (e *Engine) RunMulti(listeners ...ListenConfig) error {
+	ctx, stop := signalContext()
+	defer stop()
+	return e.RunMultiWithContext(ctx, listeners...)
+}
+
+// RunMultiWithContext 同 RunMulti，ctx 结束时退出
+func (e *Engine) RunMultiWithContext(ctx context.Context, listeners ...ListenConfig) error {
+	if len(listeners) == 0 {
+		return errors.New("nxjgo: no listener configured")
+	}
+	ls := make([]net.Listener, 0, len(listeners))
+	closeAll := func() {
+		for _, l := range ls {
+			_ = l.Close()
+		}
+		e.closeInherited()
+	}
+	httpsPort := ""
+	for _, c := range listeners {
+		l := c.Listener
+		if l != nil {
+			// 显式传入的监听器优先，同地址的继承套接字不再使用
+			if dup, _ := e.takeInherited(l.Addr().Network(), l.Addr().String()); dup != nil {
+				_ = dup.Close()
+			}
+		} else {
+			var err error
+			if l, err = e.listen("tcp", c.Addr); err != nil {
+				closeAll()
+				return err
+			}
+		}
+		ls = append(ls, l)
+		if c.TLS && httpsPort == "" {
+			_, httpsPort, _ = net.SplitHostPort(l.Addr().String())
+		}
+	}
+
+	reloadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var tlsConf *tls.Config
+	endpoints := make([]endpoint, len(listeners))
+	for i, c := range listeners {
+		srv, l := e.newServer(c.Addr), ls[i]
+		if c.H2C {
+			e.enableH2C(srv)
+		}
+		if c.TLS {
+			if tlsConf == nil {
+				var err error
+				if tlsConf, err = e.newTLSConfig(reloadCtx, e.TLS); err != nil {
+					closeAll()
+					return err
+				}
+			}
+			srv.TLSConfig = tlsConf
+		} else if c.RedirectHTTPS {
+			port := c.RedirectPort
+			if port == "" {
+				port = httpsPort
+			}
+			srv.Handler = redirectHTTPS(srv.Handler, port, c.RedirectExempt)
+		}
+		endpoints[i] = endpoint{srv: srv, l: l, tls: c.TLS}
+		debugPrint("Listening on %s %s", l.Addr().Network(), l.Addr())
+	}
+	return e.serve(ctx, endpoints...)
+}
+
+// redirectHTTPS 将请求以 308 重定向到 https 的同一地址，exempt 中的路径交给 h 处理
+func redirectHTTPS(h http.Handler, port string, exempt []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, p := range exempt {
+			if r.URL.Path == p || strings.HasSuffix(p, "/") && strings.HasPrefix(r.URL.Path, p) {
+				h.ServeHTTP(w, r)
+				return
+			}
+		}
+		host := r.Host
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		} else {
+			host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,156 @@
+package nxjgo
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRunMulti(t *testing.T) {
+	dir := t.TempDir()
+	e := New()
+	e.TLS = TLSConfig{Certs: []TLSCert{newServerCert(t, newTestCA(t), "localhost").write(t, dir, "server")}}
+	g := e.Group("")
+	g.Get("/healthz", func(ctx *Context) { _ = ctx.String(http.StatusOK, "ok") })
+	g.Get("/orders", func(ctx *Context) { _ = ctx.String(http.StatusOK, "orders %v", ctx.R.TLS != nil) })
+
+	plain, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secure, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, httpsPort, _ := net.SplitHostPort(secure.Addr().String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- e.RunMultiWithContext(ctx,
+			ListenConfig{Listener: plain, RedirectHTTPS: true, RedirectExempt: []string{"/healthz"}},
+			ListenConfig{Listener: secure, TLS: true},
+		)
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	defer client.CloseIdleConnections()
+	httpURL := "http://" + plain.Addr().String()
+	waitServing(t, httpURL+"/healthz")
+
+	get := func(url string) (*http.Response, string) {
+		t.Helper()
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp, string(b)
+	}
+	if resp, body := get(httpURL + "/healthz"); resp.StatusCode != http.StatusOK || body != "ok" {
+		t.Errorf("exempt path: code = %d, body = %q", resp.StatusCode, body)
+	}
+	resp, _ := get(httpURL + "/orders?page=2")
+	want := "https://127.0.0.1:" + httpsPort + "/orders?page=2"
+	if resp.StatusCode != http.StatusPermanentRedirect || resp.Header.Get("Location") != want {
+		t.Errorf("redirect: code = %d, Location = %q, want %q", resp.StatusCode, resp.Header.Get("Location"), want)
+	}
+	if resp, body := get(want); resp.StatusCode != http.StatusOK || body != "orders true" {
+		t.Errorf("https: code = %d, body = %q", resp.StatusCode, body)
+	}
+
+	client.CloseIdleConnections()
+	cancel()
+	if err := <-runErr; err != nil {
+		t.Errorf("RunMultiWithContext = %v", err)
+	}
+	for _, l := range []net.Listener{plain, secure} {
+		if conn, err := net.Dial("tcp", l.Addr().String()); err == nil {
+			conn.Close()
+			t.Errorf("%s still accepting connections", l.Addr())
+		}
+	}
+}
+
+// TestRunMultiInherited 显式传入监听器时，同地址和未被使用的继承套接字都会被关闭
+func TestRunMultiInherited(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Skip("listener file descriptors not supported:", err)
+	}
+	same, err := net.FileListener(f)
+	_ = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unused, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := New()
+	e.Group("").Get("/ping", func(ctx *Context) {})
+	e.inheritOnce.Do(func() { e.inherited = []net.Listener{same, unused} })
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- e.RunMultiWithContext(ctx, ListenConfig{Listener: l}) }()
+	waitServing(t, "http://"+l.Addr().String()+"/ping")
+
+	for _, inherited := range []net.Listener{same, unused} {
+		_ = inherited.(*net.TCPListener).SetDeadline(time.Now().Add(time.Second))
+		if _, err := inherited.Accept(); !errors.Is(err, net.ErrClosed) {
+			t.Errorf("inherited socket %s: Accept = %v, want closed", inherited.Addr(), err)
+		}
+	}
+	cancel()
+	if err := <-runErr; err != nil {
+		t.Errorf("RunMultiWithContext = %v", err)
+	}
+}
+
+func TestRedirectHTTPS(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		host   string
+		port   string
+		exempt []string
+		path   string
+		want   string
+	}{
+		{"example.com", "443", nil, "/a?b=1", "https://example.com/a?b=1"},
+		{"example.com:80", "", nil, "/", "https://example.com/"},
+		{"example.com:8080", "8443", nil, "/", "https://example.com:8443/"},
+		{"[::1]:80", "443", nil, "/", "https://[::1]/"},
+		{"[::1]", "8443", nil, "/", "https://[::1]:8443/"},
+		{"example.com", "443", []string{"/.well-known/"}, "/.well-known/acme-challenge/x", ""},
+		{"example.com", "443", []string{"/.well-known/"}, "/.well-known", "https://example.com/.well-known"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		redirectHTTPS(h, tt.port, tt.exempt).ServeHTTP(w, req)
+		if got := w.Header().Get("Location"); got != tt.want {
+			t.Errorf("%s%s: Location = %q, want %q", tt.host, tt.path, got, tt.want)
+		}
+		if tt.want != "" && w.Code != http.StatusPermanentRedirect {
+			t.Errorf("%s%s: code = %d", tt.host, tt.path, w.Code)
+		}
+	}
+}
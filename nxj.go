@@ -1,14 +1,21 @@
 package nxjgo
 
 import (
-	"fmt"
+	"context"
 	"github.com/Komorebi695/nxjgo/config"
 	nxjLog "github.com/Komorebi695/nxjgo/log"
 	"github.com/Komorebi695/nxjgo/render"
+	"io/fs"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
+	"time"
 )
 
 const (
@@ -19,112 +26,303 @@ type HandlerFunc func(ctx *Context)
 
 type routerGroup struct {
 	name               string
+	prefix             string // 含父路由组的完整前缀，如 /api/v1，根路由组为空串
+	parent             *routerGroup
+	host               *hostPattern
 	handleFuncMap      map[string]map[string]HandlerFunc      // map[路由]map[方法]HandlerFunc
 	middlewaresFuncMap map[string]map[string][]MiddlewareFunc // map[路由]map[方法]MiddlewareFunc
 	//handleMethodMap    map[string][]string
 	treeNode    *treeNode
 	middlewares []MiddlewareFunc
+	engine      *Engine
 }
 
 type MiddlewareFunc func(handlerFunc HandlerFunc) HandlerFunc
 
+// HandlerMiddleware 将调用 ctx.Next() 的处理函数转换为 MiddlewareFunc，两种风格的中间件可以混用。
+// ctx.Next() 执行后续的中间件和处理函数并返回；处理函数未调用 ctx.Next() 且未 Abort 时，返回后自动执行后续部分。
+func HandlerMiddleware(h HandlerFunc) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			i := len(ctx.nexts)
+			ctx.nexts = append(ctx.nexts, nextFrame{next: next})
+			h(ctx)
+			called := ctx.nexts[i].called
+			ctx.nexts = ctx.nexts[:i]
+			if !called && !ctx.aborted {
+				next(ctx)
+			}
+		}
+	}
+}
+
 type router struct {
-	groups []*routerGroup
+	groups []*routerGroup // 按匹配顺序排列，见 groupLess
 	engine *Engine
 }
 
+// Group 创建顶层路由组，name 为空时为根路由组
 func (r *router) Group(name string) *routerGroup {
+	return r.newGroup(name, nil)
+}
+
+// Group 创建子路由组，继承父路由组的前缀和中间件
+func (r *routerGroup) Group(name string) *routerGroup {
+	return r.engine.router.newGroup(name, r)
+}
+
+func (r *router) newGroup(name string, parent *routerGroup) *routerGroup {
+	g := r.makeGroup(name, parent)
+	r.insertGroup(g)
+	r.engine.invalidate()
+	return g
+}
+
+// makeGroup 创建路由组但不加入匹配列表
+func (r *router) makeGroup(name string, parent *routerGroup) *routerGroup {
+	prefix := ""
+	if parent != nil {
+		prefix = parent.prefix
+	}
+	if name = strings.Trim(name, "/"); name != "" {
+		prefix += "/" + name
+	}
 	g := &routerGroup{
 		name:               name,
+		prefix:             prefix,
+		parent:             parent,
 		handleFuncMap:      make(map[string]map[string]HandlerFunc),
 		middlewaresFuncMap: make(map[string]map[string][]MiddlewareFunc),
 		//handleMethodMap:    make(map[string][]string),
-		treeNode: &treeNode{name: "/", children: make([]*treeNode, 0)},
+		treeNode: &treeNode{},
+		engine:   r.engine,
 	}
-	g.Use(r.engine.middle...)
-	r.groups = append(r.groups, g)
 	return g
 }
 
+// insertGroup 按匹配顺序将路由组加入列表
+func (r *router) insertGroup(g *routerGroup) {
+	i := sort.Search(len(r.groups), func(i int) bool {
+		return groupLess(g, r.groups[i])
+	})
+	r.groups = append(r.groups, nil)
+	copy(r.groups[i+1:], r.groups[i:])
+	r.groups[i] = g
+}
+
+// groupLess 路由组匹配顺序: 带 Host 的路由组优先(不含参数的 Host 更优先)，其次前缀越长越优先
+func groupLess(a, b *routerGroup) bool {
+	ha, hb := a.hostPattern(), b.hostPattern()
+	if (ha != nil) != (hb != nil) {
+		return ha != nil
+	}
+	if ha != nil && ha.params != hb.params {
+		return ha.params < hb.params
+	}
+	return len(a.prefix) > len(b.prefix)
+}
+
+// Host 限定路由组只匹配指定的 Host，如 api.example.com、{tenant}.example.com。
+// {name} 匹配一级域名并可通过 ctx.Param(name) 获取，子路由组继承父路由组的 Host。
+// 带 Host 的路由组优先于不带 Host 的路由组匹配，未匹配任何 Host 的请求由不带 Host 的路由组处理。
+func (r *routerGroup) Host(pattern string) *routerGroup {
+	r.host = newHostPattern(pattern)
+	sort.SliceStable(r.engine.groups, func(i, j int) bool {
+		return groupLess(r.engine.groups[i], r.engine.groups[j])
+	})
+	r.engine.invalidate()
+	return r
+}
+
+func (r *routerGroup) hostPattern() *hostPattern {
+	for g := r; g != nil; g = g.parent {
+		if g.host != nil {
+			return g.host
+		}
+	}
+	return nil
+}
+
+// Use 注册路由组中间件，作用于本组及子路由组，后注册的在外层先执行。
+// 路由组中间件在引擎级中间件之内、路由级中间件之外执行；早期版本中路由级中间件在最外层、引擎级中间件在最内层
 func (r *routerGroup) Use(middlewareFunc ...MiddlewareFunc) {
 	r.middlewares = append(r.middlewares, middlewareFunc...)
+	r.engine.invalidate()
+}
+
+// UseHandler 以处理函数列表的形式注册路由组中间件，见 HandlerMiddleware
+func (r *routerGroup) UseHandler(handlers ...HandlerFunc) {
+	for _, h := range handlers {
+		r.Use(HandlerMiddleware(h))
+	}
+}
+
+// match 按前缀严格匹配请求路径，返回组内的相对路径
+func (r *routerGroup) match(path string) (string, bool) {
+	if !strings.HasPrefix(path, r.prefix) {
+		return "", false
+	}
+	rest := path[len(r.prefix):]
+	if rest != "" && rest[0] != '/' {
+		return "", false
+	}
+	return rest, true
+}
+
+func (r *routerGroup) middlewareCount() int {
+	n := len(r.middlewares)
+	if r.parent != nil {
+		n += r.parent.middlewareCount()
+	}
+	return n
 }
 
 //func (r *routerGroup) PostHandle(middlewareFunc ...MiddlewareFunc) {
 //	r.postMiddlewares = append(r.postMiddlewares, middlewareFunc...)
 //}
 
-func (r *routerGroup) methodHandle(name string, method string, h HandlerFunc, ctx *Context) {
-	// 组通用中间件
-	if r.middlewares != nil {
-		for _, middlewareFunc := range r.middlewares {
-			h = middlewareFunc(h)
-		}
+func (r *routerGroup) handle(name string, method string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) *Route {
+	route, err := r.TryHandle(method, name, handlerFunc, middlewareFunc...)
+	if err != nil {
+		panic(err)
 	}
-	// 组路由级别
-	funcMiddleware := r.middlewaresFuncMap[name][method]
-	if funcMiddleware != nil {
-		for _, middlewareFunc := range funcMiddleware {
-			h = middlewareFunc(h)
-		}
+	return route
+}
+
+// Handle 注册路由，路由冲突时 panic
+func (r *routerGroup) Handle(method string, name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) *Route {
+	return r.handle(name, method, handlerFunc, middlewareFunc...)
+}
+
+// TryHandle 注册路由，路由冲突时返回 *RouteConflictError 且不修改已有路由
+func (r *routerGroup) TryHandle(method string, name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) (*Route, error) {
+	route, err := r.addRoute(method, name, handlerFunc, middlewareFunc...)
+	if err != nil {
+		return nil, err
 	}
-	h(ctx)
+	r.engine.invalidate()
+	return route, nil
 }
 
-func (r *routerGroup) handle(name string, method string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
+func (r *routerGroup) addRoute(method string, name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) (*Route, error) {
+	if err := r.checkConflict(name, method); err != nil {
+		return nil, err
+	}
 	_, ok := r.handleFuncMap[name]
 	if !ok {
 		r.handleFuncMap[name] = make(map[string]HandlerFunc)
 		r.middlewaresFuncMap[name] = make(map[string][]MiddlewareFunc)
 	}
-	_, ok = r.handleFuncMap[name][method]
-	if ok {
-		panic("There are duplicate routes.")
-	}
 	r.handleFuncMap[name][method] = handlerFunc
 	r.middlewaresFuncMap[name][method] = append(r.middlewaresFuncMap[name][method], middlewareFunc...)
 	//r.handleMethodMap[method] = append(r.handleMethodMap[method], name)
 	r.treeNode.Put(name)
+	return &Route{group: r, method: method, path: name}, nil
+}
+
+func (r *routerGroup) Any(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) *Route {
+	return r.handle(name, ANY, handlerFunc, middlewareFunc...)
+}
+
+func (r *routerGroup) Get(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodGet, handlerFunc, middlewareFunc...)
 }
 
-func (r *routerGroup) Any(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
-	r.handle(name, ANY, handlerFunc, middlewareFunc...)
+func (r *routerGroup) Post(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodPost, handlerFunc, middlewareFunc...)
 }
 
-func (r *routerGroup) Get(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
-	r.handle(name, http.MethodGet, handlerFunc, middlewareFunc...)
+func (r *routerGroup) Delete(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodDelete, handlerFunc, middlewareFunc...)
 }
 
-func (r *routerGroup) Post(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
-	r.handle(name, http.MethodPost, handlerFunc, middlewareFunc...)
+func (r *routerGroup) Put(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodPut, handlerFunc, middlewareFunc...)
 }
 
-func (r *routerGroup) Delete(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
-	r.handle(name, http.MethodDelete, handlerFunc, middlewareFunc...)
+func (r *routerGroup) Patch(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodPatch, handlerFunc, middlewareFunc...)
 }
 
-func (r *routerGroup) Put(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
-	r.handle(name, http.MethodPut, handlerFunc, middlewareFunc...)
+func (r *routerGroup) Options(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodOptions, handlerFunc, middlewareFunc...)
 }
 
-func (r *routerGroup) Patch(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
-	r.handle(name, http.MethodPatch, handlerFunc, middlewareFunc...)
+func (r *routerGroup) Head(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) *Route {
+	return r.handle(name, http.MethodHead, handlerFunc, middlewareFunc...)
 }
 
-func (r *routerGroup) Options(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
-	r.handle(name, http.MethodOptions, handlerFunc, middlewareFunc...)
+// RouteInfo 路由信息
+type RouteInfo struct {
+	Method      string
+	Path        string
+	Name        string
+	Host        string
+	Group       string
+	Handler     string
+	HandlerFunc HandlerFunc
+	Middlewares int
+}
+
+type RoutesInfo []RouteInfo
+
+// Routes 返回所有已注册的路由，按路由组匹配顺序、组内按路径和方法排序
+func (e *Engine) Routes() (routes RoutesInfo) {
+	e.tableMu.Lock()
+	defer e.tableMu.Unlock()
+	names := make(map[Route]string, len(e.namedRoutes))
+	for name, route := range e.namedRoutes {
+		names[*route] = name
+	}
+	for _, g := range e.groups {
+		start := len(routes)
+		for name, methods := range g.handleFuncMap {
+			for method, h := range methods {
+				routes = append(routes, RouteInfo{
+					Method:      method,
+					Path:        g.prefix + name,
+					Name:        names[Route{group: g, method: method, path: name}],
+					Host:        g.hostPattern().String(),
+					Group:       strings.TrimPrefix(g.prefix, "/"),
+					Handler:     nameOfFunction(h),
+					HandlerFunc: h,
+					Middlewares: len(e.middle) + g.middlewareCount() + len(g.middlewaresFuncMap[name][method]),
+				})
+			}
+		}
+		groupRoutes := routes[start:]
+		sort.Slice(groupRoutes, func(i, j int) bool {
+			if groupRoutes[i].Path != groupRoutes[j].Path {
+				return groupRoutes[i].Path < groupRoutes[j].Path
+			}
+			return groupRoutes[i].Method < groupRoutes[j].Method
+		})
+	}
+	return routes
 }
 
-func (r *routerGroup) Head(name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
-	r.handle(name, http.MethodHead, handlerFunc, middlewareFunc...)
+func (e *Engine) debugPrintRoutes() {
+	if !IsDebugging() {
+		return
+	}
+	for _, route := range e.Routes() {
+		if route.Name != "" {
+			debugPrint("%-7s %-35s --> %s (%d middlewares) name=%s", route.Method, route.Path, route.Handler, route.Middlewares, route.Name)
+			continue
+		}
+		debugPrint("%-7s %-35s --> %s (%d middlewares)", route.Method, route.Path, route.Handler, route.Middlewares)
+	}
 }
 
 func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := e.pool.Get().(*Context)
+	ctx.reset()
+	ctx.writer.reset(w)
 	ctx.R = r
-	ctx.W = w
+	ctx.W = &ctx.writer
 	ctx.Logger = e.Logger
 	e.httpRequestHandle(ctx)
+	ctx.writer.WriteHeaderNow()
 	e.pool.Put(ctx)
 }
 
@@ -136,12 +334,66 @@ type Engine struct {
 	Logger       *nxjLog.Logger
 	middle       []MiddlewareFunc
 	errorHandler ErrorHandler
+	noRoute      HandlerFunc
+	noMethod     HandlerFunc
+	table        atomic.Pointer[routeTable]
+	tableMu      sync.Mutex // 保护路由表编译及 AddRoute、RemoveRoute 等运行时变更
+	namedRoutes  map[string]*Route
+	// RedirectTrailingSlash 路由不存在但去掉或补上末尾的 / 后存在时重定向，默认开启
+	RedirectTrailingSlash bool
+	// RedirectFixedPath 路由不存在时清理路径(.、..、重复的 /)后重定向
+	RedirectFixedPath bool
+	// RedirectCaseInsensitive 与 RedirectFixedPath 一起使用，忽略大小写查找路由
+	RedirectCaseInsensitive bool
+	// UseRawPath 使用 URL.RawPath 匹配路由
+	UseRawPath bool
+	// UnescapePathValues UseRawPath 开启时对路由参数进行反转义，默认开启
+	UnescapePathValues bool
+	// Server 创建 http.Server 时使用的配置
+	Server ServerConfig
+	// TLS RunTLS 使用的证书、证书重新加载和客户端证书校验配置
+	TLS TLSConfig
+	// ShutdownTimeout 收到退出信号后等待处理中请求完成的最长时间，默认 10 秒
+	ShutdownTimeout time.Duration
+
+	// UpgradeOnSignal 收到 SIGUSR2 时执行 Upgrade
+	UpgradeOnSignal bool
+	// UpgradeTimeout Upgrade 等待新进程就绪的最长时间，默认 30 秒
+	UpgradeTimeout time.Duration
+
+	srvMu        sync.Mutex
+	servers      []*http.Server
+	listeners    []net.Listener
+	inherited    []net.Listener
+	inheritOnce  sync.Once
+	inheritErr   error
+	upgrading    atomic.Bool
+	closing      bool
+	handoverDone chan struct{} // Upgrade 交接完成后关闭
+	serving      sync.WaitGroup
+	onStart      []func(ctx context.Context) error
+	onShutdown   []func(ctx context.Context) error
+	h2cConns     atomic.Int64
+	connMu       sync.Mutex
+	busyConns    map[net.Conn]struct{} // 已接受但尚未处理完请求的连接
+	shutdownOnce sync.Once
+	shutdownErr  error
 }
 
 func New() *Engine {
 	engine := &Engine{
-		router: &router{},
+		router:      &router{},
+		noRoute:     defaultNoRoute,
+		noMethod:    defaultNoMethod,
+		namedRoutes: make(map[string]*Route),
+
+		RedirectTrailingSlash: true,
+		UnescapePathValues:    true,
+		ShutdownTimeout:       10 * time.Second,
+		UpgradeTimeout:        30 * time.Second,
 	}
+	engine.router.engine = engine
+	engine.funcMap = template.FuncMap{"url": engine.URL}
 	engine.pool.New = func() any {
 		return engine.allocateContext()
 	}
@@ -150,26 +402,33 @@ func New() *Engine {
 
 func Default() *Engine {
 	engine := New()
-	engine.funcMap = nil
 	engine.HTMLRender = render.HTMLRender{}
 	engine.Logger = nxjLog.Default()
 	logPath, ok := config.Conf.Log["path"]
 	if ok {
 		engine.Logger.SetLogPath(logPath.(string))
 	}
+	if err := engine.LoadServerConfByConf(); err != nil {
+		panic(err)
+	}
 	// 默认日志目录
 	//engine.Logger.SetLogPath("./log")
 	engine.Use(Logging, Recovery)
-	engine.router.engine = engine
 	return engine
 }
 
 func (e *Engine) allocateContext() any {
-	return &Context{engine: e}
+	ctx := &Context{engine: e, Params: make(Params, 0, e.routeTable().maxParams)}
+	ctx.writer.ctx = ctx
+	return ctx
 }
 
+// SetFuncMap 设置模板函数，内置的 url 函数会被保留，可被同名函数覆盖
 func (e *Engine) SetFuncMap(funcMap template.FuncMap) {
-	e.funcMap = funcMap
+	e.funcMap = template.FuncMap{"url": e.URL}
+	for name, fn := range funcMap {
+		e.funcMap[name] = fn
+	}
 }
 
 func (e *Engine) LoadTemplate(pattern string) {
@@ -187,21 +446,30 @@ func (e *Engine) LoadTemplateByConf() {
 	e.SetHtmlTemplate(t)
 }
 
+// LoadTemplateFS 从 fs.FS 加载模板，可配合 embed.FS 和 DevFS 使用
+func (e *Engine) LoadTemplateFS(fsys fs.FS, patterns ...string) {
+	t := template.Must(template.New("").Funcs(e.funcMap).ParseFS(fsys, patterns...))
+	e.SetHtmlTemplate(t)
+}
+
 func (e *Engine) SetHtmlTemplate(t *template.Template) {
 	e.HTMLRender = render.HTMLRender{Template: t}
 }
 
+// Run 监听 addr 并阻塞，收到 SIGINT、SIGTERM 后优雅退出
 func (e *Engine) Run(addr string) {
-	http.Handle("/", e)
-	err := http.ListenAndServe(addr, nil)
-	if err != nil {
+	ctx, stop := signalContext()
+	defer stop()
+	if err := e.RunWithContext(ctx, addr); err != nil {
 		log.Fatal(err)
 	}
 }
 
+// RunTLS 以 HTTPS 监听 addr 并阻塞，收到 SIGINT、SIGTERM 后优雅退出，见 RunTLSWithContext
 func (e *Engine) RunTLS(addr, certFile, keyFile string) {
-	err := http.ListenAndServeTLS(addr, certFile, keyFile, e.Handler())
-	if err != nil {
+	ctx, stop := signalContext()
+	defer stop()
+	if err := e.RunTLSWithContext(ctx, addr, certFile, keyFile); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -212,32 +480,225 @@ func (e *Engine) Handler() http.Handler {
 
 func (e *Engine) httpRequestHandle(ctx *Context) {
 	method := ctx.R.Method
-	for _, g := range e.groups {
-		routerName := SubStringLast(ctx.R.URL.Path, "/"+g.name)
-		node := g.treeNode.Get(routerName)
-		if node != nil && node.isEnd {
-			// 路由匹配上了
-			handle, ok := g.handleFuncMap[node.routerName][ANY]
-			if ok {
-				g.methodHandle(node.routerName, ANY, handle, ctx)
-				return
-			}
-			handle, ok = g.handleFuncMap[node.routerName][method]
+	path := ctx.R.URL.Path
+	raw := false
+	if e.UseRawPath && len(ctx.R.URL.RawPath) > 0 {
+		path = ctx.R.URL.RawPath
+		raw = true
+	}
+	host := requestHost(ctx.R)
+	t := e.routeTable()
+	g, fullPath, ok := t.lookup(host, path, &ctx.Params)
+	if ok {
+		// 路由匹配上了
+		ctx.group = g.routerGroup
+		ctx.route = fullPath
+		if raw && e.UnescapePathValues {
+			unescapeParams(ctx.Params)
+		}
+		handlers := g.handlers[fullPath]
+		handle, ok := handlers[ANY]
+		if ok {
+			handle(ctx)
+			return
+		}
+		handle, ok = handlers[method]
+		if ok {
+			handle(ctx)
+			return
+		}
+		// HEAD 未注册时由 GET 处理，丢弃响应体
+		if method == http.MethodHead {
+			handle, ok = handlers[http.MethodGet]
 			if ok {
-				g.methodHandle(node.routerName, method, handle, ctx)
+				ctx.W = &headResponseWriter{ResponseWriter: ctx.W}
+				handle(ctx)
 				return
 			}
-			ctx.W.WriteHeader(http.StatusMethodNotAllowed)
-			fmt.Fprintf(ctx.W, "%s %s not allowed.", ctx.R.RequestURI, method)
+		}
+		ctx.W.Header().Set("Allow", allowedMethods(handlers))
+		if method == http.MethodOptions {
+			t.options(ctx)
+			return
+		}
+		t.noMethod(ctx)
+		return
+	}
+	if method != http.MethodConnect && path != "/" {
+		if fixed, ok := e.fixPath(t, host, path); ok {
+			wrapMiddlewares(func(ctx *Context) {
+				redirectFixedPath(ctx, fixed, raw)
+			}, e.middle)(ctx)
 			return
 		}
 	}
-	ctx.W.WriteHeader(http.StatusNotFound)
-	fmt.Fprintf(ctx.W, "%s not found.", ctx.R.RequestURI)
+	t.noRoute(ctx)
+}
+
+// lookup 按路由组匹配顺序查找路由，返回路由组和组内路由模板
+func (t *routeTable) lookup(host, path string, params *Params) (*compiledGroup, string, bool) {
+	for _, g := range t.groups {
+		routerName, ok := g.match(path)
+		if !ok {
+			continue
+		}
+		*params = (*params)[:0]
+		if g.host != nil && !g.host.match(host, params) {
+			continue
+		}
+		fullPath, ok := g.tree.Get(routerName, params)
+		if ok {
+			return g, fullPath, true
+		}
+	}
+	*params = (*params)[:0]
+	return nil, "", false
+}
+
+// fixPath 根据 RedirectTrailingSlash、RedirectFixedPath 查找可重定向到的规范路径
+func (e *Engine) fixPath(t *routeTable, host, path string) (string, bool) {
+	var params Params
+	if e.RedirectTrailingSlash {
+		if p, ok := t.lookupTrailingSlash(host, path, &params); ok {
+			return p, true
+		}
+	}
+	if !e.RedirectFixedPath {
+		return "", false
+	}
+	cleaned := cleanPath(path)
+	if cleaned != path {
+		if _, _, ok := t.lookup(host, cleaned, &params); ok {
+			return cleaned, true
+		}
+		if e.RedirectTrailingSlash {
+			if p, ok := t.lookupTrailingSlash(host, cleaned, &params); ok {
+				return p, true
+			}
+		}
+	}
+	if e.RedirectCaseInsensitive {
+		if p, ok := t.lookupCaseInsensitive(host, cleaned); ok {
+			return p, true
+		}
+		if e.RedirectTrailingSlash && cleaned != "/" {
+			if p, ok := t.lookupCaseInsensitive(host, toggleTrailingSlash(cleaned)); ok {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (t *routeTable) lookupTrailingSlash(host, path string, params *Params) (string, bool) {
+	if path == "/" {
+		return "", false
+	}
+	p := toggleTrailingSlash(path)
+	if _, _, ok := t.lookup(host, p, params); ok {
+		return p, true
+	}
+	return "", false
+}
+
+func (t *routeTable) lookupCaseInsensitive(host, path string) (string, bool) {
+	for _, g := range t.groups {
+		if g.host != nil && !g.host.match(host, nil) {
+			continue
+		}
+		if len(path) < len(g.prefix) || !equalFoldASCII(path[:len(g.prefix)], g.prefix) {
+			continue
+		}
+		rest := path[len(g.prefix):]
+		if rest != "" && rest[0] != '/' {
+			continue
+		}
+		if fixed, ok := g.tree.findCaseInsensitive(rest); ok {
+			return g.prefix + fixed, true
+		}
+	}
+	return "", false
+}
+
+// redirectFixedPath GET 请求使用 301，其余方法使用 308 以保留请求方法和请求体
+func redirectFixedPath(ctx *Context, path string, raw bool) {
+	code := http.StatusMovedPermanently
+	if ctx.R.Method != http.MethodGet {
+		code = http.StatusPermanentRedirect
+	}
+	if !raw {
+		path = (&url.URL{Path: path}).EscapedPath()
+	}
+	if ctx.R.URL.RawQuery != "" {
+		path += "?" + ctx.R.URL.RawQuery
+	}
+	_ = ctx.Redirect(code, path)
+}
+
+func unescapeParams(params Params) {
+	for i := range params {
+		if v, err := url.PathUnescape(params[i].Value); err == nil {
+			params[i].Value = v
+		}
+	}
+}
+
+// NoRoute 设置路由不存在(404)时的处理函数，引擎级中间件(Use)同样作用于它
+func (e *Engine) NoRoute(handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
+	e.noRoute = wrapMiddlewares(handlerFunc, middlewareFunc)
+	e.invalidate()
+}
+
+// NoMethod 设置路由存在但方法不匹配(405)时的处理函数，调用前已写入 Allow 响应头
+func (e *Engine) NoMethod(handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) {
+	e.noMethod = wrapMiddlewares(handlerFunc, middlewareFunc)
+	e.invalidate()
+}
+
+func defaultNoRoute(ctx *Context) {
+	_ = ctx.String(http.StatusNotFound, "%s not found.", ctx.R.RequestURI)
+}
+
+func defaultNoMethod(ctx *Context) {
+	_ = ctx.String(http.StatusMethodNotAllowed, "%s %s not allowed.", ctx.R.RequestURI, ctx.R.Method)
+}
+
+// autoOptions 未注册 OPTIONS 时的默认应答，调用前已写入 Allow 响应头
+func autoOptions(ctx *Context) {
+	ctx.StatusCode = http.StatusNoContent
+	ctx.W.WriteHeader(http.StatusNoContent)
+}
+
+// allowedMethods 返回路由可接受的方法，用于 Allow 响应头
+func allowedMethods(methods map[string]HandlerFunc) string {
+	allow := make([]string, 0, len(methods)+2)
+	for method := range methods {
+		allow = append(allow, method)
+	}
+	if _, ok := methods[http.MethodGet]; ok {
+		if _, ok := methods[http.MethodHead]; !ok {
+			allow = append(allow, http.MethodHead)
+		}
+	}
+	if _, ok := methods[http.MethodOptions]; !ok {
+		allow = append(allow, http.MethodOptions)
+	}
+	sort.Strings(allow)
+	return strings.Join(allow, ", ")
 }
 
+// Use 注册引擎级中间件，作用于所有路由组(无论路由组创建先后)以及 NoRoute、NoMethod 和自动应答的 OPTIONS。
+// 引擎级中间件在最外层，先于路由组和路由级中间件执行；早期版本中它在路由组中间件之内，是不兼容的变更
 func (e *Engine) Use(middle ...MiddlewareFunc) {
 	e.middle = append(e.middle, middle...)
+	e.invalidate()
+}
+
+// UseHandler 以处理函数列表的形式注册引擎级中间件，见 HandlerMiddleware
+func (e *Engine) UseHandler(handlers ...HandlerFunc) {
+	for _, h := range handlers {
+		e.Use(HandlerMiddleware(h))
+	}
 }
 
 type ErrorHandler func(err error) (int, any)
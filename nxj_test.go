@@ -0,0 +1,461 @@
+package nxjgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func performRequest(e *Engine, method, path string) *httptest.ResponseRecorder {
+	return serve(e, newRequest(method, path))
+}
+
+func newRequest(method, path string) *http.Request {
+	return httptest.NewRequest(method, path, nil)
+}
+
+func serve(e *Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestContextParams(t *testing.T) {
+	e := New()
+	g := e.Group("user")
+	g.Get("/get/:id", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "id=%s", ctx.Param("id"))
+	})
+	g.Get("/file/**", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "file=%s", ctx.Param("**"))
+	})
+	g.Get("/:name/*/info", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "%v", ctx.Params)
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/user/get/42", "id=42"},
+		{"/user/file/a/b/c.txt", "file=a/b/c.txt"},
+		{"/user/ly/x/info", "[{name ly} {* x}]"},
+		{"/user/get/7", "id=7"},
+	}
+	for _, tt := range tests {
+		w := performRequest(e, http.MethodGet, tt.path)
+		if w.Body.String() != tt.want {
+			t.Errorf("GET %s = %q, want %q", tt.path, w.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestServeHTTPConcurrent(t *testing.T) {
+	e := New()
+	g := e.Group("user")
+	g.Get("/:id", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "%s %s", ctx.FullPath(), ctx.Param("id"))
+	})
+	g.Get("/:id/orders/*", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "%s %s %s", ctx.FullPath(), ctx.Param("id"), ctx.Param("*"))
+	})
+	g.Get("/static/**", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "%s %s", ctx.FullPath(), ctx.Param("**"))
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/user/1", "/user/:id 1"},
+		{"/user/2/orders/9", "/user/:id/orders/* 2 9"},
+		{"/user/static/a/b", "/user/static/** a/b"},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				tt := tests[(i+j)%len(tests)]
+				w := performRequest(e, http.MethodGet, tt.path)
+				if got := w.Body.String(); got != tt.want {
+					t.Errorf("GET %s = %q, want %q", tt.path, got, tt.want)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func userShow(ctx *Context) {}
+
+func TestEngineRoutes(t *testing.T) {
+	e := New()
+	e.Use(Logging)
+	g := e.Group("user")
+	g.Post("/:id", userShow, Recovery)
+	g.Get("/:id", userShow)
+	g.Any("/list", func(ctx *Context) {})
+
+	routes := e.Routes()
+	want := []RouteInfo{
+		{Method: http.MethodGet, Path: "/user/:id", Group: "user", Handler: "github.com/Komorebi695/nxjgo.userShow", Middlewares: 1},
+		{Method: http.MethodPost, Path: "/user/:id", Group: "user", Handler: "github.com/Komorebi695/nxjgo.userShow", Middlewares: 2},
+		{Method: ANY, Path: "/user/list", Group: "user", Handler: "github.com/Komorebi695/nxjgo.TestEngineRoutes.func1", Middlewares: 1},
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("Routes() = %+v, want %d routes", routes, len(want))
+	}
+	for i, r := range routes {
+		if r.HandlerFunc == nil {
+			t.Errorf("Routes()[%d].HandlerFunc is nil", i)
+		}
+		r.HandlerFunc = nil
+		if !reflect.DeepEqual(r, want[i]) {
+			t.Errorf("Routes()[%d] = %+v, want %+v", i, r, want[i])
+		}
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	e := New()
+	// 自动应答的 OPTIONS 同样经过引擎级中间件，如 CORS 预检
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			ctx.W.Header().Set("Access-Control-Allow-Origin", "*")
+			next(ctx)
+		}
+	})
+	g := e.Group("user")
+	g.Get("/:id", func(ctx *Context) {
+		ctx.W.Header().Set("X-User", ctx.Param("id"))
+		_ = ctx.String(http.StatusOK, "user %s", ctx.Param("id"))
+	})
+	g.Post("/:id", func(ctx *Context) {})
+	g.Options("/list", func(ctx *Context) {
+		ctx.W.WriteHeader(http.StatusTeapot)
+	})
+
+	w := performRequest(e, http.MethodDelete, "/user/1")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("DELETE Allow = %q", allow)
+	}
+
+	w = performRequest(e, http.MethodOptions, "/user/1")
+	if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("OPTIONS = %d %q", w.Code, w.Header().Get("Allow"))
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("engine middleware did not run for automatic OPTIONS")
+	}
+
+	w = performRequest(e, http.MethodOptions, "/user/list")
+	if w.Code != http.StatusTeapot {
+		t.Errorf("explicit OPTIONS status = %d, want %d", w.Code, http.StatusTeapot)
+	}
+
+	w = performRequest(e, http.MethodHead, "/user/1")
+	if w.Code != http.StatusOK || w.Header().Get("X-User") != "1" || w.Body.Len() != 0 {
+		t.Errorf("HEAD = %d %q %q", w.Code, w.Header().Get("X-User"), w.Body.String())
+	}
+}
+
+func TestNoRouteNoMethod(t *testing.T) {
+	e := New()
+	var logged []string
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			next(ctx)
+			logged = append(logged, fmt.Sprintf("%s %d", ctx.R.URL.Path, ctx.StatusCode))
+		}
+	})
+	g := e.Group("user")
+	g.Get("/:id", func(ctx *Context) {})
+
+	w := performRequest(e, http.MethodGet, "/order/1")
+	if w.Code != http.StatusNotFound || w.Body.String() != "/order/1 not found." {
+		t.Errorf("default 404 = %d %q", w.Code, w.Body.String())
+	}
+
+	e.NoRoute(func(ctx *Context) {
+		_ = ctx.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+	})
+	e.NoMethod(func(ctx *Context) {
+		_ = ctx.JSON(http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	})
+	w = performRequest(e, http.MethodGet, "/order/1")
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"error":"not found"}` {
+		t.Errorf("NoRoute = %d %q", w.Code, w.Body.String())
+	}
+	w = performRequest(e, http.MethodPut, "/user/1")
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") == "" {
+		t.Errorf("NoMethod = %d Allow=%q", w.Code, w.Header().Get("Allow"))
+	}
+
+	want := []string{"/order/1 404", "/order/1 404", "/user/1 405"}
+	if !reflect.DeepEqual(logged, want) {
+		t.Errorf("middleware saw %v, want %v", logged, want)
+	}
+}
+
+func TestNestedGroups(t *testing.T) {
+	e := New()
+	mark := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx *Context) {
+				ctx.W.Header().Add("X-Middleware", name)
+				next(ctx)
+			}
+		}
+	}
+	root := e.Group("")
+	root.Get("/", func(ctx *Context) { _ = ctx.String(http.StatusOK, "root") })
+	root.Get("/admin/user/:id", func(ctx *Context) { _ = ctx.String(http.StatusOK, "admin %s", ctx.Param("id")) })
+
+	api := e.Group("api")
+	api.Use(mark("api"))
+	v1 := api.Group("/v1/")
+	v1.Use(mark("v1"))
+	users := v1.Group("users")
+	users.Get("/:id", func(ctx *Context) { _ = ctx.String(http.StatusOK, "%s %s", ctx.FullPath(), ctx.Param("id")) })
+	users.Get("", func(ctx *Context) { _ = ctx.String(http.StatusOK, "list") })
+
+	user := e.Group("user")
+	user.Get("/:id", func(ctx *Context) { _ = ctx.String(http.StatusOK, "user %s", ctx.Param("id")) })
+
+	tests := []struct {
+		path        string
+		body        string
+		middlewares []string
+	}{
+		{"/", "root", nil},
+		{"/api/v1/users/7", "/api/v1/users/:id 7", []string{"api", "v1"}},
+		{"/api/v1/users", "list", []string{"api", "v1"}},
+		{"/user/3", "user 3", nil},
+		{"/admin/user/3", "admin 3", nil},
+		{"/userx/3", "/userx/3 not found.", nil},
+	}
+	for _, tt := range tests {
+		w := performRequest(e, http.MethodGet, tt.path)
+		if w.Body.String() != tt.body {
+			t.Errorf("GET %s = %q, want %q", tt.path, w.Body.String(), tt.body)
+		}
+		if got := w.Header().Values("X-Middleware"); !reflect.DeepEqual(got, tt.middlewares) {
+			t.Errorf("GET %s middlewares = %v, want %v", tt.path, got, tt.middlewares)
+		}
+	}
+}
+
+func TestRedirectFixedPath(t *testing.T) {
+	e := New()
+	e.RedirectFixedPath = true
+	e.RedirectCaseInsensitive = true
+	g := e.Group("hello")
+	g.Get("/ping", func(ctx *Context) { _ = ctx.String(http.StatusOK, "pong") })
+	g.Post("/dir/", func(ctx *Context) {})
+	g.Get("/Files/**", func(ctx *Context) {})
+
+	tests := []struct {
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "/hello/ping", http.StatusOK, ""},
+		{http.MethodGet, "/hello/ping/", http.StatusMovedPermanently, "/hello/ping"},
+		{http.MethodGet, "/hello/ping/?a=1", http.StatusMovedPermanently, "/hello/ping?a=1"},
+		{http.MethodPost, "/hello/dir", http.StatusPermanentRedirect, "/hello/dir/"},
+		{http.MethodGet, "/hello//ping", http.StatusMovedPermanently, "/hello/ping"},
+		{http.MethodGet, "/hello/x/../ping", http.StatusMovedPermanently, "/hello/ping"},
+		{http.MethodGet, "/HELLO/Ping", http.StatusMovedPermanently, "/hello/ping"},
+		{http.MethodGet, "/Hello/files/A/b", http.StatusMovedPermanently, "/hello/Files/A/b"},
+		{http.MethodGet, "/hello/pong", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(e, tt.method, tt.path)
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s = %d %q, want %d %q", tt.method, tt.path, w.Code, w.Header().Get("Location"), tt.code, tt.location)
+		}
+	}
+
+	e.RedirectTrailingSlash = false
+	if w := performRequest(e, http.MethodGet, "/hello/ping/"); w.Code != http.StatusNotFound {
+		t.Errorf("RedirectTrailingSlash disabled: status = %d, want 404", w.Code)
+	}
+}
+
+func TestUseRawPath(t *testing.T) {
+	e := New()
+	e.UseRawPath = true
+	g := e.Group("file")
+	g.Get("/:name/info", func(ctx *Context) { _ = ctx.String(http.StatusOK, ctx.Param("name")) })
+
+	w := performRequest(e, http.MethodGet, "/file/a%2Fb/info")
+	if w.Code != http.StatusOK || w.Body.String() != "a/b" {
+		t.Errorf("UseRawPath = %d %q, want 200 \"a/b\"", w.Code, w.Body.String())
+	}
+	e.UnescapePathValues = false
+	if w = performRequest(e, http.MethodGet, "/file/a%2Fb/info"); w.Body.String() != "a%2Fb" {
+		t.Errorf("UnescapePathValues disabled = %q, want \"a%%2Fb\"", w.Body.String())
+	}
+}
+
+func TestTypedParams(t *testing.T) {
+	e := New()
+	g := e.Group("")
+	g.Get("/user/:id<int>", func(ctx *Context) {
+		id, err := ctx.ParamInt("id")
+		_ = ctx.String(http.StatusOK, "%d %v", id+1, err)
+	})
+	g.Get("/at/:date<date>/:id<uuid>", func(ctx *Context) {
+		date, _ := ctx.ParamDate("date")
+		id, _ := ctx.ParamUUID("id")
+		_ = ctx.String(http.StatusOK, "%s %s", date.Weekday(), id)
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/user/41", http.StatusOK, "42 <nil>"},
+		{"/user/ly", http.StatusNotFound, "/user/ly not found."},
+		{"/at/2024-05-20/6BA7B810-9DAD-11D1-80B4-00C04FD430C8", http.StatusOK, "Monday 6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+	}
+	for _, tt := range tests {
+		w := performRequest(e, http.MethodGet, tt.path)
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+	}
+}
+
+func TestHostGroups(t *testing.T) {
+	e := New()
+	e.Group("").Get("/", func(ctx *Context) { _ = ctx.String(http.StatusOK, "default") })
+	tenant := e.Group("").Host("{tenant}.example.com")
+	tenant.Get("/", func(ctx *Context) { _ = ctx.String(http.StatusOK, "tenant %s", ctx.Param("tenant")) })
+	tenant.Group("users").Get("/:id", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "%v", ctx.Params)
+	})
+	e.Group("").Host("Admin.Example.com").Get("/", func(ctx *Context) { _ = ctx.String(http.StatusOK, "admin") })
+
+	tests := []struct {
+		host string
+		path string
+		want string
+	}{
+		{"admin.example.com", "/", "admin"},
+		{"acme.example.com:8080", "/", "tenant acme"},
+		{"acme.example.com", "/users/7", "[{tenant acme} {id 7}]"},
+		{"a.b.example.com", "/", "default"},
+		{"example.com", "/", "default"},
+		{"localhost:8080", "/", "default"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Body.String() != tt.want {
+			t.Errorf("GET %s%s = %q, want %q", tt.host, tt.path, w.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var order []string
+	mark := func(name string) MiddlewareFunc {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx *Context) {
+				order = append(order, name)
+				next(ctx)
+			}
+		}
+	}
+	e := New()
+	e.Use(mark("engine1"))
+	api := e.Group("api")
+	api.Use(mark("api1"), mark("api2"))
+	v1 := api.Group("v1")
+	v1.Use(mark("v1"))
+	v1.Get("/ping", func(ctx *Context) { order = append(order, "handler") }, mark("route"))
+	// 路由组创建之后注册的引擎级中间件同样生效
+	e.Use(mark("engine2"))
+
+	performRequest(e, http.MethodGet, "/api/v1/ping")
+	want := []string{"engine2", "engine1", "api2", "api1", "v1", "route", "handler"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("middleware order = %v, want %v", order, want)
+	}
+
+	order = nil
+	performRequest(e, http.MethodGet, "/none")
+	if want = []string{"engine2", "engine1"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("NoRoute middleware order = %v, want %v", order, want)
+	}
+}
+
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
+
+func benchmarkMiddleware(next HandlerFunc) HandlerFunc {
+	return func(ctx *Context) {
+		next(ctx)
+	}
+}
+
+func newBenchmarkEngine() *Engine {
+	e := New()
+	e.Use(benchmarkMiddleware, benchmarkMiddleware)
+	g := e.Group("api").Group("user")
+	g.Use(benchmarkMiddleware, benchmarkMiddleware)
+	g.Get("/:id", func(ctx *Context) {}, benchmarkMiddleware)
+	return e
+}
+
+func BenchmarkEngineServeHTTP(b *testing.B) {
+	e := newBenchmarkEngine()
+	req := httptest.NewRequest(http.MethodGet, "/api/user/1", nil)
+	w := &discardResponseWriter{header: make(http.Header)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.ServeHTTP(w, req)
+	}
+}
+
+// BenchmarkEngineServeHTTPPerRequestChain 模拟每次请求重新包装中间件链，用于对比预编译的效果
+func BenchmarkEngineServeHTTPPerRequestChain(b *testing.B) {
+	e := newBenchmarkEngine()
+	t := e.routeTable()
+	req := httptest.NewRequest(http.MethodGet, "/api/user/1", nil)
+	w := &discardResponseWriter{header: make(http.Header)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx := e.pool.Get().(*Context)
+		ctx.reset()
+		ctx.W, ctx.R = w, req
+		g, route, _ := t.lookup("", req.URL.Path, &ctx.Params)
+		h := wrapMiddlewares(g.handleFuncMap[route][http.MethodGet], g.middlewaresFuncMap[route][http.MethodGet])
+		for p := g.routerGroup; p != nil; p = p.parent {
+			h = wrapMiddlewares(h, p.middlewares)
+		}
+		wrapMiddlewares(h, e.middle)(ctx)
+		e.pool.Put(ctx)
+	}
+}
@@ -0,0 +1,121 @@
+package nxjgo
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+const noWritten = -1
+
+// responseWriter 包装 http.ResponseWriter，记录状态码和写入的字节数。
+// WriteHeader 只记录状态码，直到第一次写入响应体或请求处理结束才真正写出，
+// 因此中间件可以在处理函数返回后修改尚未写出响应体的请求的状态码。
+type responseWriter struct {
+	http.ResponseWriter
+	ctx    *Context
+	status int
+	size   int
+}
+
+func (w *responseWriter) reset(writer http.ResponseWriter) {
+	w.ResponseWriter = writer
+	w.status = http.StatusOK
+	w.size = noWritten
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if code > 0 && !w.Written() {
+		w.status = code
+		w.ctx.StatusCode = code
+	}
+}
+
+// WriteHeaderNow 立即写出状态码和响应头
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+		w.ctx.StatusCode = w.status
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// ReadFrom 被包装的 ResponseWriter 支持 io.ReaderFrom 时直接交给它，
+// 保留 http.ServeContent 等使用的 sendfile 优化
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.WriteHeaderNow()
+	var n int64
+	var err error
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(w.ResponseWriter, r)
+	}
+	w.size += int(n)
+	return n, err
+}
+
+// Status 返回已设置的状态码
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Size 返回已写入的响应体字节数，未写出响应头时为 -1
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+// Written 响应头是否已写出
+func (w *responseWriter) Written() bool {
+	return w.size != noWritten
+}
+
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("nxjgo: response writer does not implement http.Hijacker")
+	}
+	if w.size < 0 {
+		w.size = 0
+	}
+	return h.Hijack()
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// headResponseWriter 用于以 GET 处理函数响应 HEAD 请求，只保留状态码和响应头
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *headResponseWriter) WriteString(s string) (int, error) {
+	return len(s), nil
+}
+
+func (w *headResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
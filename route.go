@@ -0,0 +1,89 @@
+package nxjgo
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Route 注册路由后返回的句柄
+type Route struct {
+	group  *routerGroup
+	method string
+	path   string
+}
+
+// Name 为路由命名，用于 Engine.URL 反向生成地址，重名时 panic
+func (r *Route) Name(name string) *Route {
+	e := r.group.engine
+	e.tableMu.Lock()
+	defer e.tableMu.Unlock()
+	routes := e.namedRoutes
+	if old, ok := routes[name]; ok && *old != *r {
+		panic(fmt.Sprintf("route name %q already used by %s %s", name, old.method, old.FullPath()))
+	}
+	routes[name] = r
+	return r
+}
+
+// FullPath 返回含路由组前缀的路由模板
+func (r *Route) FullPath() string {
+	return r.group.prefix + r.path
+}
+
+// URL 根据路由名生成地址，params 为参数名和值交替排列，如 URL("user.show", "id", 5)。
+// :name 与 * 的值按单段转义，** 的值按 / 分段转义。
+func (e *Engine) URL(name string, params ...any) (string, error) {
+	e.tableMu.Lock()
+	route, ok := e.namedRoutes[name]
+	e.tableMu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("route %q not found", name)
+	}
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("route %q: params must be key/value pairs", name)
+	}
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		key, ok := params[i].(string)
+		if !ok {
+			return "", fmt.Errorf("route %q: param key %v is not a string", name, params[i])
+		}
+		values[key] = fmt.Sprint(params[i+1])
+	}
+
+	segments := strings.Split(route.FullPath(), "/")
+	for i, seg := range segments {
+		if !isWildSegment(seg) {
+			continue
+		}
+		key, constraint := seg, (*paramConstraint)(nil)
+		if seg[0] == ':' {
+			key, constraint = mustParseParamSegment(seg)
+		}
+		v, ok := values[key]
+		if !ok {
+			return "", fmt.Errorf("route %q: missing param %q", name, key)
+		}
+		delete(values, key)
+		if constraint != nil && !constraint.match(v) {
+			return "", fmt.Errorf("route %q: param %q value %q does not match <%s>", name, key, v, constraint.expr)
+		}
+		if key == "**" {
+			parts := strings.Split(v, "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+			segments[i] = strings.Join(parts, "/")
+			continue
+		}
+		if v == "" {
+			return "", fmt.Errorf("route %q: param %q is empty", name, key)
+		}
+		segments[i] = url.PathEscape(v)
+	}
+	for key := range values {
+		return "", fmt.Errorf("route %q: unknown param %q", name, key)
+	}
+	return strings.Join(segments, "/"), nil
+}
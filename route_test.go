@@ -0,0 +1,65 @@
+package nxjgo
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestEngineURL(t *testing.T) {
+	e := New()
+	user := e.Group("api").Group("user")
+	user.Get("/:id<int>", func(ctx *Context) {}).Name("user.show")
+	user.Get("/:id/files/**", func(ctx *Context) {}).Name("user.files")
+	e.Group("").Get("/", func(ctx *Context) {}).Name("home")
+
+	tests := []struct {
+		name   string
+		params []any
+		want   string
+	}{
+		{"user.show", []any{"id", 5}, "/api/user/5"},
+		{"user.files", []any{"id", "a b", "**", "docs/x?.txt"}, "/api/user/a%20b/files/docs/x%3F.txt"},
+		{"home", nil, "/"},
+	}
+	for _, tt := range tests {
+		got, err := e.URL(tt.name, tt.params...)
+		if err != nil || got != tt.want {
+			t.Errorf("URL(%q, %v) = %q, %v, want %q", tt.name, tt.params, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range [][]any{
+		{"user.show"},
+		{"user.show", "id", "ly"},
+		{"user.show", "id", 1, "page", 2},
+		{"user.missing", "id", 1},
+		{"user.show", "id"},
+	} {
+		if got, err := e.URL(bad[0].(string), bad[1:]...); err == nil {
+			t.Errorf("URL(%v) = %q, want error", bad, got)
+		}
+	}
+
+	routes := e.Routes()
+	for _, r := range routes {
+		if r.Path == "/api/user/:id<int>" && r.Name != "user.show" {
+			t.Errorf("Routes() name = %q, want user.show", r.Name)
+		}
+	}
+}
+
+func TestTemplateURLFunc(t *testing.T) {
+	e := New()
+	e.Group("user").Get("/:id", func(ctx *Context) {}).Name("user.show")
+	e.SetFuncMap(template.FuncMap{"upper": strings.ToUpper})
+	tpl := template.Must(template.New("t").Funcs(e.funcMap).Parse(`{{upper "a"}} {{url "user.show" "id" 7}}`))
+	e.SetHtmlTemplate(tpl)
+	e.Group("page").Get("/", func(ctx *Context) { _ = ctx.Template("t", nil) })
+
+	w := performRequest(e, http.MethodGet, "/page/")
+	if w.Body.String() != "A /user/7" {
+		t.Errorf("template = %q, want %q", w.Body.String(), "A /user/7")
+	}
+}
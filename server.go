@@ -0,0 +1,301 @@
+package nxjgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Komorebi695/nxjgo/config"
+	nxjLog "github.com/Komorebi695/nxjgo/log"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// OnStart 注册启动钩子，在开始监听前按注册顺序执行，返回错误时不再启动
+func (e *Engine) OnStart(fn func(ctx context.Context) error) {
+	e.onStart = append(e.onStart, fn)
+}
+
+// OnShutdown 注册退出钩子，在处理中的请求完成后按注册的相反顺序执行，
+// 用于关闭数据库连接、协程池等资源，先注册的资源最后关闭
+func (e *Engine) OnShutdown(fn func(ctx context.Context) error) {
+	e.onShutdown = append(e.onShutdown, fn)
+}
+
+// RunWithContext 监听 addr 并阻塞，ctx 结束或调用 Shutdown 后优雅退出。
+// 正常退出时返回 nil，否则返回监听、启动钩子或退出过程中的错误
+// 进程通过 LISTEN_FDS 继承了监听套接字(如 systemd socket activation)时忽略 addr，在继承的套接字上服务
+func (e *Engine) RunWithContext(ctx context.Context, addr string) error {
+	listeners, err := e.inheritedListeners()
+	if err != nil {
+		return err
+	}
+	if len(listeners) > 0 {
+		debugPrint("Listening on %d inherited sockets", len(listeners))
+		return e.serveListeners(ctx, listeners...)
+	}
+	if addr == "" {
+		addr = ":http"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return e.serve(ctx, endpoint{srv: e.newServer(addr), l: l})
+}
+
+// Shutdown 停止接受新连接，等待处理中的请求完成后依次执行退出钩子并关闭日志文件。
+// ctx 到期时强制关闭剩余连接；多次调用只执行一次，返回相同的结果
+func (e *Engine) Shutdown(ctx context.Context) error {
+	e.shutdownOnce.Do(func() {
+		e.srvMu.Lock()
+		e.closing = true
+		servers := e.servers
+		e.srvMu.Unlock()
+
+		var errs []error
+		for _, srv := range servers {
+			if err := srv.Shutdown(ctx); err != nil {
+				errs = append(errs, err)
+				_ = srv.Close()
+			}
+		}
+		if err := waitH2C(ctx, &e.h2cConns); err != nil {
+			errs = append(errs, err)
+		}
+		for i := len(e.onShutdown) - 1; i >= 0; i-- {
+			if err := e.onShutdown[i](ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if e.Logger != nil {
+			e.Logger.CloseWriter()
+		}
+		e.shutdownErr = errors.Join(errs...)
+	})
+	return e.shutdownErr
+}
+
+// ServerConfig http.Server 配置，零值字段使用 net/http 的默认值
+type ServerConfig struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	// ErrorLog 接收 http.Server 内部错误(如 TLS 握手失败)，为 nil 时使用 Engine.Logger
+	ErrorLog *nxjLog.Logger
+	// H2C 开启 HTTP/2 明文支持，见 RunH2C
+	H2C bool
+	// BaseContext 为每个监听器提供请求的根 context，见 http.Server.BaseContext
+	BaseContext func(net.Listener) context.Context
+}
+
+// LoadServerConfByConf 从配置文件的 [server] 段加载 ServerConfig，
+// 时间可以是 "5s" 这样的字符串或整数秒
+func (e *Engine) LoadServerConfByConf() error {
+	durations := map[string]*time.Duration{
+		"read_timeout":        &e.Server.ReadTimeout,
+		"read_header_timeout": &e.Server.ReadHeaderTimeout,
+		"write_timeout":       &e.Server.WriteTimeout,
+		"idle_timeout":        &e.Server.IdleTimeout,
+	}
+	for key, value := range config.Conf.Server {
+		if d, ok := durations[key]; ok {
+			v, err := confDuration(value)
+			if err != nil {
+				return fmt.Errorf("config server.%s: %w", key, err)
+			}
+			*d = v
+			continue
+		}
+		if key == "h2c" {
+			v, ok := value.(bool)
+			if !ok {
+				return fmt.Errorf("config server.%s: %v is not a boolean", key, value)
+			}
+			e.Server.H2C = v
+			continue
+		}
+		if key == "max_header_bytes" {
+			v, ok := value.(int64)
+			if !ok {
+				return fmt.Errorf("config server.%s: %v is not an integer", key, value)
+			}
+			e.Server.MaxHeaderBytes = int(v)
+		}
+	}
+	return nil
+}
+
+func confDuration(value any) (time.Duration, error) {
+	switch v := value.(type) {
+	case string:
+		return time.ParseDuration(v)
+	case int64:
+		return time.Duration(v) * time.Second, nil
+	default:
+		return 0, fmt.Errorf("invalid duration %v", value)
+	}
+}
+
+func (e *Engine) newServer(addr string) *http.Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           e,
+		ReadTimeout:       e.Server.ReadTimeout,
+		ReadHeaderTimeout: e.Server.ReadHeaderTimeout,
+		WriteTimeout:      e.Server.WriteTimeout,
+		IdleTimeout:       e.Server.IdleTimeout,
+		MaxHeaderBytes:    e.Server.MaxHeaderBytes,
+		BaseContext:       e.Server.BaseContext,
+		ConnState:         e.trackConn,
+	}
+	if e.Server.H2C {
+		e.enableH2C(srv)
+	}
+	logger := e.Server.ErrorLog
+	if logger == nil {
+		logger = e.Logger
+	}
+	if logger != nil {
+		srv.ErrorLog = log.New(errorLogWriter{logger}, "", 0)
+	}
+	return srv
+}
+
+// errorLogWriter 将 http.Server 的错误日志转为 nxjLog.Logger 的 Error 级别日志
+type errorLogWriter struct {
+	logger *nxjLog.Logger
+}
+
+func (w errorLogWriter) Write(p []byte) (int, error) {
+	w.logger.Error(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// endpoint 监听器及在其上服务的 http.Server，多个 endpoint 可以共用一个 http.Server
+type endpoint struct {
+	srv *http.Server
+	l   net.Listener
+	tls bool
+}
+
+// serve 执行启动钩子后在所有 endpoint 上开始服务，阻塞到退出完成，任意一个失败时整体退出
+func (e *Engine) serve(ctx context.Context, endpoints ...endpoint) error {
+	closeListeners := func() {
+		for _, ep := range endpoints {
+			_ = ep.l.Close()
+		}
+	}
+	e.closeInherited()
+	e.debugPrintRoutes()
+	e.routeTable()
+	for _, fn := range e.onStart {
+		if err := fn(ctx); err != nil {
+			closeListeners()
+			return err
+		}
+	}
+	e.srvMu.Lock()
+	if e.closing {
+		e.srvMu.Unlock()
+		closeListeners()
+		return http.ErrServerClosed
+	}
+	for i, ep := range endpoints {
+		if i == 0 || ep.srv != endpoints[i-1].srv {
+			e.servers = append(e.servers, ep.srv)
+		}
+		e.listeners = append(e.listeners, ep.l)
+	}
+	e.srvMu.Unlock()
+
+	errCh := make(chan error, len(endpoints))
+	e.serving.Add(len(endpoints))
+	for _, ep := range endpoints {
+		go func(ep endpoint) {
+			defer e.serving.Done()
+			if ep.tls {
+				errCh <- ep.srv.ServeTLS(ep.l, "", "")
+				return
+			}
+			errCh <- ep.srv.Serve(ep.l)
+		}(ep)
+	}
+	notifyUpgradeReady()
+	if e.UpgradeOnSignal {
+		stop := e.upgradeOnSignal()
+		defer stop()
+	}
+	select {
+	case err := <-errCh:
+		e.srvMu.Lock()
+		handover := e.handoverDone
+		e.srvMu.Unlock()
+		if handover != nil {
+			// Upgrade 关闭了监听器，等待交接完成
+			<-handover
+			return e.Shutdown(context.Background())
+		}
+		if errors.Is(err, http.ErrServerClosed) {
+			// 其他协程调用了 Shutdown，等待其完成
+			return e.Shutdown(context.Background())
+		}
+		// 监听失败时同样执行退出流程，释放启动钩子中打开的资源
+		_ = e.shutdownWithTimeout()
+		return err
+	case <-ctx.Done():
+		debugPrint("Shutting down server...")
+		return e.shutdownWithTimeout()
+	}
+}
+
+// trackConn 记录已接受但尚未处理完请求的连接，Upgrade 交接时等待这些连接
+func (e *Engine) trackConn(c net.Conn, state http.ConnState) {
+	e.connMu.Lock()
+	defer e.connMu.Unlock()
+	switch state {
+	case http.StateNew, http.StateActive:
+		if e.busyConns == nil {
+			e.busyConns = make(map[net.Conn]struct{})
+		}
+		e.busyConns[c] = struct{}{}
+	default:
+		delete(e.busyConns, c)
+	}
+}
+
+// waitConns 等待 trackConn 记录的连接全部空闲或关闭，ctx 到期时不再等待
+func (e *Engine) waitConns(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		e.connMu.Lock()
+		n := len(e.busyConns)
+		e.connMu.Unlock()
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Engine) shutdownWithTimeout() error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.ShutdownTimeout)
+	defer cancel()
+	return e.Shutdown(ctx)
+}
+
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
@@ -0,0 +1,188 @@
+package nxjgo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/BurntSushi/toml"
+	"github.com/Komorebi695/nxjgo/config"
+	nxjLog "github.com/Komorebi695/nxjgo/log"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func waitServing(t *testing.T, url string) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("server at %s did not start", url)
+}
+
+func TestRunWithContextGracefulShutdown(t *testing.T) {
+	addr := freeAddr(t)
+	e := New()
+	started, release := make(chan struct{}), make(chan struct{})
+	g := e.Group("")
+	g.Get("/ping", func(ctx *Context) {})
+	g.Get("/slow", func(ctx *Context) {
+		close(started)
+		<-release
+		_ = ctx.String(http.StatusOK, "done")
+	})
+	var events []string
+	e.OnStart(func(ctx context.Context) error {
+		events = append(events, "start")
+		return nil
+	})
+	e.OnShutdown(func(ctx context.Context) error {
+		events = append(events, "close db")
+		return nil
+	})
+	e.OnShutdown(func(ctx context.Context) error {
+		events = append(events, "close pool")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- e.RunWithContext(ctx, addr) }()
+	waitServing(t, "http://"+addr+"/ping")
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-runErr:
+		t.Fatalf("RunWithContext returned %v before in-flight request finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if b := <-body; b != "done" {
+		t.Errorf("in-flight request body = %q, want %q", b, "done")
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("RunWithContext = %v", err)
+	}
+	if want := []string{"start", "close pool", "close db"}; !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+	if _, err := http.Get("http://" + addr + "/ping"); err == nil {
+		t.Error("server still accepting connections after shutdown")
+	}
+}
+
+func TestShutdownErrors(t *testing.T) {
+	addr := freeAddr(t)
+	e := New()
+	e.Group("").Get("/ping", func(ctx *Context) {})
+	errClose := errors.New("close failed")
+	e.OnShutdown(func(ctx context.Context) error { return errClose })
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- e.RunWithContext(context.Background(), addr) }()
+	waitServing(t, "http://"+addr+"/ping")
+
+	if err := e.Shutdown(context.Background()); !errors.Is(err, errClose) {
+		t.Errorf("Shutdown = %v, want %v", err, errClose)
+	}
+	if err := <-runErr; !errors.Is(err, errClose) {
+		t.Errorf("RunWithContext = %v, want %v", err, errClose)
+	}
+	if err := e.RunWithContext(context.Background(), addr); !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("RunWithContext after Shutdown = %v, want %v", err, http.ErrServerClosed)
+	}
+
+	errStart := errors.New("start failed")
+	e = New()
+	e.OnStart(func(ctx context.Context) error { return errStart })
+	if err := e.RunWithContext(context.Background(), freeAddr(t)); !errors.Is(err, errStart) {
+		t.Errorf("RunWithContext with failing OnStart = %v, want %v", err, errStart)
+	}
+}
+
+func TestLoadServerConfByConf(t *testing.T) {
+	old := config.Conf.Server
+	defer func() { config.Conf.Server = old }()
+
+	var conf config.NXJConfig
+	if _, err := toml.Decode(`
+[server]
+read_timeout = "5s"
+read_header_timeout = 2
+write_timeout = "1m"
+idle_timeout = "90s"
+max_header_bytes = 65536
+`, &conf); err != nil {
+		t.Fatal(err)
+	}
+	config.Conf.Server = conf.Server
+	e := New()
+	if err := e.LoadServerConfByConf(); err != nil {
+		t.Fatal(err)
+	}
+	srv := e.newServer(":0")
+	if srv.ReadTimeout != 5*time.Second || srv.ReadHeaderTimeout != 2*time.Second ||
+		srv.WriteTimeout != time.Minute || srv.IdleTimeout != 90*time.Second || srv.MaxHeaderBytes != 65536 {
+		t.Errorf("server = %+v", srv)
+	}
+
+	config.Conf.Server = map[string]any{"read_timeout": "soon"}
+	if err := New().LoadServerConfByConf(); err == nil {
+		t.Error("invalid duration accepted")
+	}
+}
+
+func TestServerErrorLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := nxjLog.New()
+	logger.Formatter = &nxjLog.TextFormatter{}
+	logger.Outs = append(logger.Outs, &nxjLog.LoggerWriter{Level: nxjLog.LevelError, Out: &buf})
+
+	type ctxKey struct{}
+	e := New()
+	e.Server.ErrorLog = logger
+	e.Server.BaseContext = func(net.Listener) context.Context {
+		return context.WithValue(context.Background(), ctxKey{}, "base")
+	}
+	srv := e.newServer(":0")
+	srv.ErrorLog.Printf("http: TLS handshake error from %s", "127.0.0.1")
+	if !bytes.Contains(buf.Bytes(), []byte("TLS handshake error from 127.0.0.1")) {
+		t.Errorf("error log = %q", buf.String())
+	}
+	if v := srv.BaseContext(nil).Value(ctxKey{}); v != "base" {
+		t.Errorf("BaseContext value = %v", v)
+	}
+	if New().newServer(":0").ErrorLog != nil {
+		t.Error("ErrorLog set without a logger")
+	}
+}
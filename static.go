@@ -0,0 +1,246 @@
+package nxjgo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticConfig 静态文件服务配置
+type StaticConfig struct {
+	// Browse 目录下没有索引文件时列出目录内容，默认返回 404
+	Browse bool
+	// Index 目录索引文件，默认 index.html
+	Index string
+	// MaxAge 大于 0 时设置 Cache-Control: public, max-age=...
+	MaxAge time.Duration
+	// Compress 客户端支持 gzip 时优先返回同名的 .gz 预压缩文件
+	Compress bool
+	// SPA 文件不存在时返回根目录的索引文件，用于单页应用的前端路由
+	SPA bool
+}
+
+// Static 将本地目录 root 挂载到 prefix 下
+func (r *routerGroup) Static(prefix, root string, conf ...StaticConfig) {
+	r.StaticFS(prefix, http.Dir(root), conf...)
+}
+
+// StaticFS 将 http.FileSystem 挂载到 prefix 下
+func (r *routerGroup) StaticFS(prefix string, fs http.FileSystem, conf ...StaticConfig) {
+	sf := newStaticFiles(fs, conf)
+	handler := func(ctx *Context) {
+		sf.serve(ctx, "/"+ctx.Param("**"))
+	}
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix != "" && prefix[0] != '/' {
+		prefix = "/" + prefix
+	}
+	if prefix != "" {
+		r.Get(prefix, handler)
+	}
+	r.Get(prefix+"/**", handler)
+}
+
+// StaticFromFS 将 fs.FS（如 embed.FS）挂载到 prefix 下
+func (r *routerGroup) StaticFromFS(prefix string, fsys fs.FS, conf ...StaticConfig) {
+	r.StaticFS(prefix, http.FS(fsys), conf...)
+}
+
+// StaticFile 将单个本地文件注册到 path
+func (r *routerGroup) StaticFile(path, file string, conf ...StaticConfig) {
+	sf, name := newStaticFiles(http.Dir(filepath.Dir(file)), conf), "/"+filepath.Base(file)
+	r.Get(path, func(ctx *Context) {
+		sf.serve(ctx, name)
+	})
+}
+
+// StaticFileFromFS 将 fs.FS 中的单个文件 name 注册到 path
+func (r *routerGroup) StaticFileFromFS(path, name string, fsys fs.FS, conf ...StaticConfig) {
+	sf, name := newStaticFiles(http.FS(fsys), conf), "/"+strings.TrimPrefix(name, "/")
+	r.Get(path, func(ctx *Context) {
+		sf.serve(ctx, name)
+	})
+}
+
+// staticFiles 一次静态文件注册对应的文件系统、配置和 ETag 缓存
+type staticFiles struct {
+	fs   http.FileSystem
+	conf StaticConfig
+	// etags 没有修改时间的文件按路径缓存内容哈希生成的 ETag，避免每次请求都读取整个文件
+	etags sync.Map
+}
+
+func newStaticFiles(fs http.FileSystem, conf []StaticConfig) *staticFiles {
+	sf := &staticFiles{fs: fs}
+	if len(conf) > 0 {
+		sf.conf = conf[0]
+	}
+	if sf.conf.Index == "" {
+		sf.conf.Index = "index.html"
+	}
+	return sf
+}
+
+func (sf *staticFiles) serve(ctx *Context, name string) {
+	name = path.Clean(name)
+	f, err := sf.fs.Open(name)
+	if err != nil {
+		sf.fallback(ctx)
+		return
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		sf.fallback(ctx)
+		return
+	}
+	if !stat.IsDir() {
+		sf.serveFile(ctx, name, f, stat)
+		return
+	}
+	if !strings.HasSuffix(ctx.R.URL.Path, "/") {
+		redirectDir(ctx)
+		return
+	}
+	index := path.Join(name, sf.conf.Index)
+	if ff, err := sf.fs.Open(index); err == nil {
+		defer ff.Close()
+		if s, err := ff.Stat(); err == nil && !s.IsDir() {
+			sf.serveFile(ctx, index, ff, s)
+			return
+		}
+	}
+	if sf.conf.Browse {
+		listDir(ctx, f)
+		return
+	}
+	sf.fallback(ctx)
+}
+
+// fallback 开启 SPA 时返回根目录索引文件，否则交给 NoRoute 处理
+func (sf *staticFiles) fallback(ctx *Context) {
+	if sf.conf.SPA {
+		index := "/" + sf.conf.Index
+		if f, err := sf.fs.Open(index); err == nil {
+			defer f.Close()
+			if s, err := f.Stat(); err == nil && !s.IsDir() {
+				ctx.W.Header().Set("Cache-Control", "no-cache")
+				sf.serveContent(ctx, index, f, s, "")
+				return
+			}
+		}
+	}
+	ctx.engine.noRoute(ctx)
+}
+
+func (sf *staticFiles) serveFile(ctx *Context, name string, f http.File, stat os.FileInfo) {
+	header := ctx.W.Header()
+	if sf.conf.MaxAge > 0 {
+		header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(sf.conf.MaxAge.Seconds())))
+	}
+	if sf.conf.Compress {
+		header.Add("Vary", "Accept-Encoding")
+		if acceptsGzip(ctx.R) {
+			if gz, err := sf.fs.Open(name + ".gz"); err == nil {
+				defer gz.Close()
+				if s, err := gz.Stat(); err == nil && !s.IsDir() {
+					if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+						header.Set("Content-Type", ct)
+					}
+					header.Set("Content-Encoding", "gzip")
+					sf.serveContent(ctx, name, gz, s, "-gzip")
+					return
+				}
+			}
+		}
+	}
+	sf.serveContent(ctx, name, f, stat, "")
+}
+
+// serveContent 设置 ETag 后交给 http.ServeContent 处理 If-None-Match、Range 等条件请求
+func (sf *staticFiles) serveContent(ctx *Context, name string, content io.ReadSeeker, stat os.FileInfo, etagSuffix string) {
+	if etag, ok := sf.etag(name+etagSuffix, content, stat); ok {
+		ctx.W.Header().Set("ETag", `W/"`+etag+etagSuffix+`"`)
+	}
+	http.ServeContent(ctx.W, ctx.R, name, stat.ModTime(), content)
+}
+
+// etag 根据修改时间和大小生成 ETag，embed.FS 没有修改时间，改用内容哈希并按 key 缓存
+func (sf *staticFiles) etag(key string, content io.ReadSeeker, stat os.FileInfo) (string, bool) {
+	if !stat.ModTime().IsZero() {
+		return fmt.Sprintf("%x-%x", stat.ModTime().UnixNano(), stat.Size()), true
+	}
+	if etag, ok := sf.etags.Load(key); ok {
+		return etag.(string), true
+	}
+	h := fnv.New64a()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", false
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", false
+	}
+	etag := fmt.Sprintf("%x-%x", h.Sum64(), stat.Size())
+	sf.etags.Store(key, etag)
+	return etag, true
+}
+
+// acceptsGzip 判断 Accept-Encoding 是否接受 gzip，q=0 表示客户端拒绝该编码
+func acceptsGzip(r *http.Request) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		enc, params, _ := strings.Cut(strings.TrimSpace(v), ";")
+		if !strings.EqualFold(strings.TrimSpace(enc), "gzip") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				return err == nil && q > 0
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func redirectDir(ctx *Context) {
+	location := ctx.R.URL.Path + "/"
+	if ctx.R.URL.RawQuery != "" {
+		location += "?" + ctx.R.URL.RawQuery
+	}
+	_ = ctx.Redirect(http.StatusMovedPermanently, location)
+}
+
+func listDir(ctx *Context, f http.File) {
+	entries, err := f.Readdir(-1)
+	if err != nil {
+		_ = ctx.String(http.StatusInternalServerError, "Error reading directory")
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	var sb strings.Builder
+	sb.WriteString("<pre>\n")
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		fmt.Fprintf(&sb, "<a href=\"%s\">%s</a>\n", (&url.URL{Path: name}).String(), html.EscapeString(name))
+	}
+	sb.WriteString("</pre>\n")
+	_ = ctx.HTML(http.StatusOK, sb.String())
+}
@@ -0,0 +1,185 @@
+package nxjgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func writeStaticFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestStatic(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte("console.log(1)"))
+	_ = zw.Close()
+	root := writeStaticFiles(t, map[string]string{
+		"index.html":    "home",
+		"app.js":        "console.log(1)",
+		"app.js.gz":     gz.String(),
+		"docs/a.txt":    "a",
+		"docs/b<c>.txt": "b",
+		"empty/x.txt":   "x",
+	})
+
+	e := New()
+	g := e.Group("")
+	g.Static("/assets", root, StaticConfig{MaxAge: time.Hour, Compress: true})
+	g.Static("browse", root, StaticConfig{Browse: true})
+	g.Static("/app", root, StaticConfig{SPA: true})
+	g.StaticFile("/favicon.txt", filepath.Join(root, "docs", "a.txt"))
+
+	tests := []struct {
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{"/assets/app.js", http.StatusOK, "console.log(1)", ""},
+		{"/assets/", http.StatusOK, "home", ""},
+		{"/assets", http.StatusMovedPermanently, "", "/assets/"},
+		{"/assets/docs", http.StatusMovedPermanently, "", "/assets/docs/"},
+		{"/assets/docs/", http.StatusNotFound, "", ""},
+		{"/assets/missing.js", http.StatusNotFound, "", ""},
+		{"/assets/../static_test.go", http.StatusNotFound, "", ""},
+		{"/browse/docs/", http.StatusOK, "<pre>\n<a href=\"a.txt\">a.txt</a>\n<a href=\"b%3Cc%3E.txt\">b&lt;c&gt;.txt</a>\n</pre>\n", ""},
+		{"/app/some/client/route", http.StatusOK, "home", ""},
+		{"/app/docs/a.txt", http.StatusOK, "a", ""},
+		{"/favicon.txt", http.StatusOK, "a", ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(e, http.MethodGet, tt.path)
+		if w.Code != tt.code {
+			t.Errorf("GET %s: code = %d, want %d", tt.path, w.Code, tt.code)
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("GET %s: body = %q, want %q", tt.path, w.Body.String(), tt.body)
+		}
+		if loc := w.Header().Get("Location"); loc != tt.location {
+			t.Errorf("GET %s: Location = %q, want %q", tt.path, loc, tt.location)
+		}
+	}
+
+	w := performRequest(e, http.MethodGet, "/assets/app.js")
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("missing ETag")
+	}
+
+	req := newRequest(http.MethodGet, "/assets/app.js")
+	req.Header.Set("If-None-Match", etag)
+	if w := serve(e, req); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: code = %d, want %d", w.Code, http.StatusNotModified)
+	}
+
+	req = newRequest(http.MethodGet, "/assets/app.js")
+	req.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+	w = serve(e, req)
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Body.String() != gz.String() {
+		t.Errorf("gzip: Content-Encoding = %q, body = %q", w.Header().Get("Content-Encoding"), w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/javascript") {
+		t.Errorf("gzip: Content-Type = %q", ct)
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("gzip variant shares ETag with identity encoding")
+	}
+
+	req = newRequest(http.MethodGet, "/assets/app.js")
+	req.Header.Set("Accept-Encoding", "gzip;q=0, br")
+	if w := serve(e, req); w.Header().Get("Content-Encoding") != "" || w.Body.String() != "console.log(1)" {
+		t.Errorf("gzip;q=0: Content-Encoding = %q, body = %q", w.Header().Get("Content-Encoding"), w.Body.String())
+	}
+
+	if w := performRequest(e, http.MethodHead, "/assets/app.js"); w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("HEAD: code = %d, body = %q", w.Code, w.Body.String())
+	}
+}
+
+func TestStaticFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"public/index.html":      {Data: []byte("home")},
+		"public/css/site.css":    {Data: []byte("body{}")},
+		"templates/hello.tmpl":   {Data: []byte(`{{define "hello"}}hi {{.}}{{end}}`)},
+		"templates/partial.tmpl": {Data: []byte(`{{define "partial"}}p{{end}}`)},
+	}
+
+	e := New()
+	e.LoadTemplateFS(fsys, "templates/*.tmpl")
+	g := e.Group("")
+	g.StaticFromFS("/", fstest.MapFS{"css/site.css": fsys["public/css/site.css"]})
+	g.StaticFileFromFS("/home", "public/index.html", fsys)
+	g.Get("/hello", func(ctx *Context) { _ = ctx.Template("hello", "nxj") })
+
+	if w := performRequest(e, http.MethodGet, "/hello"); w.Body.String() != "hi nxj" {
+		t.Errorf("template = %q, want %q", w.Body.String(), "hi nxj")
+	}
+	if w := performRequest(e, http.MethodGet, "/home"); w.Code != http.StatusOK || w.Body.String() != "home" {
+		t.Errorf("GET /home: code = %d, body = %q", w.Code, w.Body.String())
+	}
+
+	w := performRequest(e, http.MethodGet, "/css/site.css")
+	if w.Code != http.StatusOK || w.Body.String() != "body{}" {
+		t.Fatalf("GET /css/site.css: code = %d, body = %q", w.Code, w.Body.String())
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("missing ETag for file without modification time")
+	}
+	req := newRequest(http.MethodGet, "/css/site.css")
+	req.Header.Set("If-None-Match", etag)
+	if w := serve(e, req); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: code = %d, want %d", w.Code, http.StatusNotModified)
+	}
+	// 内容哈希只在第一次请求时计算
+	fsys["public/css/site.css"].Data = []byte("body{x")
+	if w := performRequest(e, http.MethodGet, "/css/site.css"); w.Header().Get("ETag") != etag {
+		t.Errorf("ETag recomputed: %q, want cached %q", w.Header().Get("ETag"), etag)
+	}
+}
+
+func TestDevFS(t *testing.T) {
+	embedded := fstest.MapFS{"a.txt": {Data: []byte("embedded")}}
+	dir := writeStaticFiles(t, map[string]string{"a.txt": "disk"})
+	defer SetMode(Mode())
+
+	tests := []struct {
+		mode string
+		dir  string
+		want string
+	}{
+		{DebugMode, dir, "disk"},
+		{DebugMode, filepath.Join(dir, "missing"), "embedded"},
+		{ReleaseMode, dir, "embedded"},
+	}
+	for _, tt := range tests {
+		SetMode(tt.mode)
+		b, err := fs.ReadFile(DevFS(embedded, tt.dir), "a.txt")
+		if err != nil || string(b) != tt.want {
+			t.Errorf("DevFS(%s, %s) = %q, %v, want %q", tt.mode, tt.dir, b, err, tt.want)
+		}
+	}
+}
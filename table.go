@@ -0,0 +1,198 @@
+package nxjgo
+
+import "fmt"
+
+// routeTable 编译后的只读路由表。
+// 中间件链在编译时一次性组装好，请求处理时不再逐层包装；注册路由或中间件后路由表失效，下次使用时重新编译。
+// 路由表编译后不再修改，路由树、Host 均为编译时的快照，运行时变更通过编译新表并原子替换生效。
+type routeTable struct {
+	groups    []*compiledGroup // 与 router.groups 顺序一致
+	noRoute   HandlerFunc
+	noMethod  HandlerFunc
+	options   HandlerFunc // 自动应答 OPTIONS
+	maxParams int         // 单个路由的最大参数数量，用于预分配 Context.Params
+}
+
+type compiledGroup struct {
+	*routerGroup
+	host     *hostPattern
+	tree     *treeNode
+	handlers map[string]map[string]HandlerFunc // map[路由]map[方法]已包装中间件的 HandlerFunc
+}
+
+// routeTable 返回当前路由表，失效时重新编译
+func (e *Engine) routeTable() *routeTable {
+	if t := e.table.Load(); t != nil {
+		return t
+	}
+	e.tableMu.Lock()
+	defer e.tableMu.Unlock()
+	if t := e.table.Load(); t != nil {
+		return t
+	}
+	t := e.compile()
+	e.table.Store(t)
+	return t
+}
+
+func (e *Engine) invalidate() {
+	e.table.Store(nil)
+}
+
+// compile 按 引擎 > 父路由组 > 路由组 > 路由 的顺序由外到内组装中间件链，
+// 同一级中后注册的中间件在外层先执行。
+// 层级顺序与早期版本相反(早期为 路由 > 路由组 > 引擎)，依赖路由级中间件先于路由组中间件执行的应用需要调整
+func (e *Engine) compile() *routeTable {
+	t := &routeTable{
+		groups:   make([]*compiledGroup, 0, len(e.groups)),
+		noRoute:  wrapMiddlewares(e.noRoute, e.middle),
+		noMethod: wrapMiddlewares(e.noMethod, e.middle),
+		options:  wrapMiddlewares(autoOptions, e.middle),
+	}
+	for _, g := range e.groups {
+		cg := &compiledGroup{
+			routerGroup: g,
+			host:        g.hostPattern(),
+			tree:        &treeNode{},
+			handlers:    make(map[string]map[string]HandlerFunc, len(g.handleFuncMap)),
+		}
+		hostParams := 0
+		if cg.host != nil {
+			hostParams = cg.host.params
+		}
+		for name, methods := range g.handleFuncMap {
+			cg.tree.Put(name)
+			if n := hostParams + countParams(name); n > t.maxParams {
+				t.maxParams = n
+			}
+			cg.handlers[name] = make(map[string]HandlerFunc, len(methods))
+			for method, h := range methods {
+				h = wrapMiddlewares(h, g.middlewaresFuncMap[name][method])
+				for p := g; p != nil; p = p.parent {
+					h = wrapMiddlewares(h, p.middlewares)
+				}
+				cg.handlers[name][method] = wrapMiddlewares(h, e.middle)
+			}
+		}
+		t.groups = append(t.groups, cg)
+	}
+	return t
+}
+
+// AddRoute 在服务运行期间注册路由。path 为完整路径，注册到前缀匹配且不带 Host 的路由组中前缀最长的一个，
+// 没有时注册到根路由组。变更在新编译的路由表上生效并原子替换，正在处理的请求继续使用旧路由表。
+func (e *Engine) AddRoute(method, path string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) (*Route, error) {
+	e.tableMu.Lock()
+	defer e.tableMu.Unlock()
+	if g, name := e.groupFor(path); g != nil {
+		return g.addRouteLocked(method, name, handlerFunc, middlewareFunc...)
+	}
+	// 新建的根路由组在路由校验通过后才加入匹配列表，冲突时不留下空路由组
+	g := e.makeGroup("", nil)
+	route, err := g.addRoute(method, path, handlerFunc, middlewareFunc...)
+	if err != nil {
+		return nil, err
+	}
+	e.insertGroup(g)
+	e.table.Store(e.compile())
+	return route, nil
+}
+
+// RemoveRoute 在服务运行期间删除 AddRoute 或 Handle 注册的路由，path 为完整路径，仅查找不带 Host 的路由组
+func (e *Engine) RemoveRoute(method, path string) error {
+	e.tableMu.Lock()
+	defer e.tableMu.Unlock()
+	for _, g := range e.groups {
+		if g.hostPattern() != nil {
+			continue
+		}
+		if name, ok := g.match(path); ok && g.removeRoute(method, name) {
+			e.table.Store(e.compile())
+			return nil
+		}
+	}
+	return fmt.Errorf("route %s %s not found", method, path)
+}
+
+// AddRoute 在服务运行期间向路由组注册路由，见 Engine.AddRoute
+func (r *routerGroup) AddRoute(method, name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) (*Route, error) {
+	r.engine.tableMu.Lock()
+	defer r.engine.tableMu.Unlock()
+	return r.addRouteLocked(method, name, handlerFunc, middlewareFunc...)
+}
+
+// RemoveRoute 在服务运行期间删除路由组内的路由，见 Engine.RemoveRoute
+func (r *routerGroup) RemoveRoute(method, name string) error {
+	e := r.engine
+	e.tableMu.Lock()
+	defer e.tableMu.Unlock()
+	if !r.removeRoute(method, name) {
+		return fmt.Errorf("route %s %s not found", method, r.prefix+name)
+	}
+	e.table.Store(e.compile())
+	return nil
+}
+
+func (r *routerGroup) addRouteLocked(method, name string, handlerFunc HandlerFunc, middlewareFunc ...MiddlewareFunc) (*Route, error) {
+	route, err := r.addRoute(method, name, handlerFunc, middlewareFunc...)
+	if err != nil {
+		return nil, err
+	}
+	r.engine.table.Store(r.engine.compile())
+	return route, nil
+}
+
+// groupFor 返回 path 所属的不带 Host 的路由组及组内路径
+func (e *Engine) groupFor(path string) (*routerGroup, string) {
+	for _, g := range e.groups {
+		if g.hostPattern() != nil {
+			continue
+		}
+		if name, ok := g.match(path); ok && name != "" {
+			return g, name
+		}
+	}
+	return nil, ""
+}
+
+// removeRoute 删除路由及其路由名，路由组的路由树只用于冲突检测，按剩余路由重建
+func (r *routerGroup) removeRoute(method, name string) bool {
+	methods := r.handleFuncMap[name]
+	if _, ok := methods[method]; !ok {
+		return false
+	}
+	delete(methods, method)
+	delete(r.middlewaresFuncMap[name], method)
+	if len(methods) == 0 {
+		delete(r.handleFuncMap, name)
+		delete(r.middlewaresFuncMap, name)
+		r.treeNode = &treeNode{}
+		for path := range r.handleFuncMap {
+			r.treeNode.Put(path)
+		}
+	}
+	for routeName, route := range r.engine.namedRoutes {
+		if route.group == r && route.method == method && route.path == name {
+			delete(r.engine.namedRoutes, routeName)
+		}
+	}
+	return true
+}
+
+// wrapMiddlewares 依次用 middlewares 包装 h，最后一个在最外层。
+// 每一层调用内层前都会检查 ctx.IsAborted()，中间件调用 Abort 后即使继续调用 next 也不再向内执行。
+func wrapMiddlewares(h HandlerFunc, middlewares []MiddlewareFunc) HandlerFunc {
+	for _, middlewareFunc := range middlewares {
+		h = middlewareFunc(abortGuard(h))
+	}
+	return h
+}
+
+func abortGuard(h HandlerFunc) HandlerFunc {
+	return func(ctx *Context) {
+		if ctx.aborted {
+			return
+		}
+		h(ctx)
+	}
+}
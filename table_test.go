@@ -0,0 +1,114 @@
+package nxjgo
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestRuntimeRoutes(t *testing.T) {
+	e := New()
+	admin := e.Group("admin")
+	admin.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			ctx.W.Header().Set("X-Group", "admin")
+			next(ctx)
+		}
+	})
+
+	if w := performRequest(e, http.MethodGet, "/admin/plugins/a"); w.Code != http.StatusNotFound {
+		t.Fatalf("before AddRoute: code = %d", w.Code)
+	}
+	route, err := e.AddRoute(http.MethodGet, "/admin/plugins/:name", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "plugin %s", ctx.Param("name"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	route.Name("plugin")
+	w := performRequest(e, http.MethodGet, "/admin/plugins/a")
+	if w.Body.String() != "plugin a" || w.Header().Get("X-Group") != "admin" {
+		t.Errorf("after AddRoute: body = %q, X-Group = %q", w.Body.String(), w.Header().Get("X-Group"))
+	}
+	if _, err := e.AddRoute(http.MethodGet, "/admin/plugins/:id", func(ctx *Context) {}); err == nil {
+		t.Error("conflicting AddRoute succeeded")
+	}
+	// 校验失败时不创建根路由组
+	if _, err := e.AddRoute(http.MethodGet, "health", func(ctx *Context) {}); err == nil || len(e.groups) != 1 {
+		t.Errorf("invalid AddRoute: err = %v, groups = %d", err, len(e.groups))
+	}
+	if _, err := e.AddRoute(http.MethodGet, "/health", func(ctx *Context) {}); err != nil {
+		t.Errorf("AddRoute outside groups: %v", err)
+	}
+	if w := performRequest(e, http.MethodGet, "/health"); w.Code != http.StatusOK {
+		t.Errorf("GET /health: code = %d", w.Code)
+	}
+
+	if err := e.RemoveRoute(http.MethodGet, "/admin/plugins/:name"); err != nil {
+		t.Fatal(err)
+	}
+	if w := performRequest(e, http.MethodGet, "/admin/plugins/a"); w.Code != http.StatusNotFound {
+		t.Errorf("after RemoveRoute: code = %d", w.Code)
+	}
+	if _, err := e.URL("plugin", "name", "a"); err == nil {
+		t.Error("route name survived RemoveRoute")
+	}
+	if err := e.RemoveRoute(http.MethodGet, "/admin/plugins/:name"); err == nil {
+		t.Error("removing a missing route succeeded")
+	}
+	if _, err := admin.AddRoute(http.MethodGet, "/plugins/:id", func(ctx *Context) {}); err != nil {
+		t.Errorf("re-adding after RemoveRoute: %v", err)
+	}
+}
+
+func TestRuntimeRoutesInFlight(t *testing.T) {
+	e := New()
+	started, release := make(chan struct{}), make(chan struct{})
+	if _, err := e.AddRoute(http.MethodGet, "/slow", func(ctx *Context) {
+		close(started)
+		<-release
+		_ = ctx.String(http.StatusOK, "done")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan string)
+	go func() {
+		done <- performRequest(e, http.MethodGet, "/slow").Body.String()
+	}()
+	<-started
+	if err := e.RemoveRoute(http.MethodGet, "/slow"); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if body := <-done; body != "done" {
+		t.Errorf("in-flight request body = %q, want %q", body, "done")
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					performRequest(e, http.MethodGet, "/dynamic/1")
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := e.AddRoute(http.MethodGet, "/dynamic/:id", func(ctx *Context) {}); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.RemoveRoute(http.MethodGet, "/dynamic/:id"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,194 @@
+package nxjgo
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSCert 证书与私钥文件
+type TLSCert struct {
+	CertFile string
+	KeyFile  string
+}
+
+// TLSConfig HTTPS 配置
+type TLSConfig struct {
+	// Certs 证书列表，按客户端 SNI 选择与域名匹配的证书，都不匹配时使用第一个
+	Certs []TLSCert
+	// ReloadInterval 大于 0 时按该间隔检查证书文件，文件修改后重新加载，加载失败时继续使用旧证书
+	ReloadInterval time.Duration
+	// ClientCAFile 客户端证书的 CA 文件(PEM)，设置后开启双向认证(mTLS)
+	ClientCAFile string
+	// ClientAuth 客户端证书的校验方式，设置 ClientCAFile 时默认为 tls.RequireAndVerifyClientCert
+	ClientAuth tls.ClientAuthType
+	// MinVersion 最低 TLS 版本，默认 TLS 1.2
+	MinVersion uint16
+}
+
+// RunTLSWithContext 以 HTTPS 监听 addr 并阻塞，使用 Engine.TLS 配置，
+// certFile、keyFile 不为空时作为第一个证书。ctx 结束或调用 Shutdown 后优雅退出
+func (e *Engine) RunTLSWithContext(ctx context.Context, addr, certFile, keyFile string) error {
+	conf := e.TLS
+	if certFile != "" || keyFile != "" {
+		conf.Certs = append([]TLSCert{{CertFile: certFile, KeyFile: keyFile}}, conf.Certs...)
+	}
+	reloadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tlsConf, err := e.newTLSConfig(reloadCtx, conf)
+	if err != nil {
+		return err
+	}
+	if addr == "" {
+		addr = ":https"
+	}
+	l, err := e.listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := e.newServer(addr)
+	srv.TLSConfig = tlsConf
+	return e.serve(ctx, endpoint{srv: srv, l: l, tls: true})
+}
+
+// newTLSConfig 加载证书并生成 tls.Config，开启重新加载时 ctx 结束后停止检查
+func (e *Engine) newTLSConfig(ctx context.Context, conf TLSConfig) (*tls.Config, error) {
+	if len(conf.Certs) == 0 {
+		return nil, errors.New("nxjgo: no TLS certificate configured")
+	}
+	store := &certStore{certs: make([]*loadedCert, len(conf.Certs))}
+	for i, c := range conf.Certs {
+		cert, err := loadCert(c)
+		if err != nil {
+			return nil, err
+		}
+		store.certs[i] = cert
+	}
+	tlsConf := &tls.Config{
+		MinVersion:     conf.MinVersion,
+		GetCertificate: store.getCertificate,
+		ClientAuth:     conf.ClientAuth,
+	}
+	if tlsConf.MinVersion == 0 {
+		tlsConf.MinVersion = tls.VersionTLS12
+	}
+	if conf.ClientCAFile != "" {
+		pem, err := os.ReadFile(conf.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("nxjgo: no certificates found in %s", conf.ClientCAFile)
+		}
+		tlsConf.ClientCAs = pool
+		if tlsConf.ClientAuth == tls.NoClientCert {
+			tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	if conf.ReloadInterval > 0 {
+		go e.reloadCerts(ctx, store, conf.ReloadInterval)
+	}
+	return tlsConf, nil
+}
+
+// certStore 可并发读取、按需替换的证书集合
+type certStore struct {
+	mu    sync.RWMutex
+	certs []*loadedCert
+}
+
+type loadedCert struct {
+	TLSCert
+	cert    *tls.Certificate
+	modTime time.Time // 证书与私钥文件中较新的修改时间
+}
+
+// getCertificate 选择与 SNI 匹配的证书，没有 SNI 或都不匹配时使用第一个
+func (s *certStore) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if hello.ServerName != "" {
+		for _, c := range s.certs {
+			if c.cert.Leaf.VerifyHostname(hello.ServerName) == nil {
+				return c.cert, nil
+			}
+		}
+	}
+	return s.certs[0].cert, nil
+}
+
+func (e *Engine) reloadCerts(ctx context.Context, store *certStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		store.mu.RLock()
+		certs := store.certs
+		store.mu.RUnlock()
+		for i, c := range certs {
+			modTime, err := certModTime(c.TLSCert)
+			if err != nil || !modTime.After(c.modTime) {
+				continue
+			}
+			cert, err := loadCert(c.TLSCert)
+			if err != nil {
+				// 证书和私钥可能尚未全部写入，下次检查时重试
+				e.logError(fmt.Sprintf("reload TLS certificate %s: %v", c.CertFile, err))
+				continue
+			}
+			store.mu.Lock()
+			store.certs[i] = cert
+			store.mu.Unlock()
+			debugPrint("Reloaded TLS certificate %s", c.CertFile)
+		}
+	}
+}
+
+func loadCert(c TLSCert) (*loadedCert, error) {
+	modTime, err := certModTime(c)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, err
+		}
+	}
+	return &loadedCert{TLSCert: c, cert: &cert, modTime: modTime}, nil
+}
+
+func certModTime(c TLSCert) (time.Time, error) {
+	var modTime time.Time
+	for _, name := range []string{c.CertFile, c.KeyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime, nil
+}
+
+func (e *Engine) logError(msg string) {
+	if e.Logger != nil {
+		e.Logger.Error(msg)
+		return
+	}
+	debugPrint(msg)
+}
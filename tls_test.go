@@ -0,0 +1,226 @@
+package nxjgo
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+var testSerial int64
+
+func newTestCert(t *testing.T, parent *testCert, tmpl *x509.Certificate) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSerial++
+	tmpl.SerialNumber = big.NewInt(testSerial)
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key}
+}
+
+func newTestCA(t *testing.T) *testCert {
+	return newTestCert(t, nil, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "nxjgo test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+}
+
+func newServerCert(t *testing.T, ca *testCert, names ...string) *testCert {
+	return newTestCert(t, ca, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: names[0]},
+		DNSNames:    names,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+}
+
+// write 将证书和私钥写入 dir 下的 name.crt、name.key
+func (c *testCert) write(t *testing.T, dir, name string) TLSCert {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := TLSCert{CertFile: filepath.Join(dir, name+".crt"), KeyFile: filepath.Join(dir, name+".key")}
+	if err := os.WriteFile(tc.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tc.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return tc
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+// startTLS 启动 HTTPS 服务并等待握手成功，开启双向认证时探测连接使用 clientCerts
+func startTLS(t *testing.T, e *Engine, clientCerts ...tls.Certificate) string {
+	t.Helper()
+	addr := freeAddr(t)
+	runErr := make(chan error, 1)
+	go func() { runErr <- e.RunTLSWithContext(context.Background(), addr, "", "") }()
+	t.Cleanup(func() {
+		if err := e.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+		if err := <-runErr; err != nil {
+			t.Errorf("RunTLSWithContext = %v", err)
+		}
+	})
+	conf := &tls.Config{InsecureSkipVerify: true, Certificates: clientCerts}
+	for i := 0; i < 100; i++ {
+		if conn, err := tls.Dial("tcp", addr, conf); err == nil {
+			conn.Close()
+			return addr
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("TLS server at %s did not start", addr)
+	return ""
+}
+
+func peerSerial(t *testing.T, addr, serverName string) int64 {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestTLSSNIAndReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	a, b := newServerCert(t, ca, "a.example.com"), newServerCert(t, ca, "*.b.example.com")
+	e := New()
+	e.TLS = TLSConfig{
+		Certs:          []TLSCert{a.write(t, dir, "a"), b.write(t, dir, "b")},
+		ReloadInterval: 10 * time.Millisecond,
+	}
+	addr := startTLS(t, e)
+
+	tests := []struct {
+		serverName string
+		want       int64
+	}{
+		{"a.example.com", a.cert.SerialNumber.Int64()},
+		{"api.b.example.com", b.cert.SerialNumber.Int64()},
+		{"other.example.com", a.cert.SerialNumber.Int64()},
+		{"", a.cert.SerialNumber.Int64()},
+	}
+	for _, tt := range tests {
+		if got := peerSerial(t, addr, tt.serverName); got != tt.want {
+			t.Errorf("SNI %q: serial = %d, want %d", tt.serverName, got, tt.want)
+		}
+	}
+
+	rotated := newServerCert(t, ca, "*.b.example.com")
+	bFiles := rotated.write(t, dir, "b")
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(bFiles.CertFile, future, future)
+	_ = os.Chtimes(bFiles.KeyFile, future, future)
+	want := rotated.cert.SerialNumber.Int64()
+	for i := 0; i < 100 && peerSerial(t, addr, "api.b.example.com") != want; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := peerSerial(t, addr, "api.b.example.com"); got != want {
+		t.Errorf("after rotation: serial = %d, want %d", got, want)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, clientCA := newTestCA(t), newTestCA(t)
+	caFile := filepath.Join(dir, "client-ca.pem")
+	_ = os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientCA.cert.Raw}), 0o600)
+	spiffe, _ := url.Parse("spiffe://mesh.local/ns/default/sa/billing")
+	client := newTestCert(t, clientCA, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "billing"},
+		URIs:        []*url.URL{spiffe},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	stranger := newTestCert(t, ca, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "stranger"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	e := New()
+	e.TLS = TLSConfig{
+		Certs:        []TLSCert{newServerCert(t, ca, "localhost").write(t, dir, "server")},
+		ClientCAFile: caFile,
+	}
+	e.Group("").Get("/whoami", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "%s %s", ctx.ClientIdentity(), ctx.ClientCert().Subject.CommonName)
+	})
+	addr := startTLS(t, e, client.tlsCertificate())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(certs ...tls.Certificate) (string, error) {
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs: roots, ServerName: "localhost", Certificates: certs,
+		}}}
+		defer c.CloseIdleConnections()
+		resp, err := c.Get("https://" + addr + "/whoami")
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	if body, err := get(client.tlsCertificate()); err != nil || body != spiffe.String()+" billing" {
+		t.Errorf("with client cert: body = %q, err = %v", body, err)
+	}
+	if _, err := get(); err == nil {
+		t.Error("request without client cert succeeded")
+	}
+	if _, err := get(stranger.tlsCertificate()); err == nil {
+		t.Error("request with untrusted client cert succeeded")
+	}
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	e := New()
+	if err := e.RunTLSWithContext(context.Background(), freeAddr(t), "", ""); err == nil {
+		t.Error("RunTLSWithContext without certificates succeeded")
+	}
+	if err := e.RunTLSWithContext(context.Background(), freeAddr(t), "missing.crt", "missing.key"); err == nil {
+		t.Error("RunTLSWithContext with missing files succeeded")
+	}
+}
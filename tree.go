@@ -4,80 +4,297 @@ import (
 	"strings"
 )
 
+// Param 路由参数
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params 按匹配顺序保存的路由参数
+type Params []Param
+
+// Get 返回第一个名称匹配的参数值
+func (ps Params) Get(name string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ByName 返回参数值，不存在时返回空串
+func (ps Params) ByName(name string) string {
+	v, _ := ps.Get(name)
+	return v
+}
+
+type nodeType uint8
+
+const (
+	static   nodeType = iota
+	param             // :name 匹配一段
+	wildcard          // * 匹配一段
+	catchAll          // ** 匹配剩余全部路径
+)
+
+// treeNode 压缩前缀树(radix tree)节点
+// 静态边按公共前缀压缩，:name、*、** 以整段为单位单独成节点。
+// 匹配优先级: 静态 > 带约束的命名参数 > 命名参数 > * > **，某一分支走不通时回溯尝试下一优先级。
 type treeNode struct {
-	name       string
-	children   []*treeNode
-	routerName string
-	isEnd      bool
+	path          string // 静态节点为边上的字符串，其余为整段，如 ":id"、"*"、"**"
+	nType         nodeType
+	key           string // 参数名
+	indices       string // 静态子节点首字节，与 children 一一对应
+	children      []*treeNode
+	paramChildren []*treeNode
+	wildChild     *treeNode
+	catchAllChild *treeNode
+	constraint    *paramConstraint
+	routerName    string
+	isEnd         bool
 }
 
 // Put path: /user/get/:id
 func (t *treeNode) Put(path string) {
-	root := t
-	strs := strings.Split(path, "/")
-	for index, name := range strs {
-		if index == 0 {
+	n := t
+	rest := path
+	for rest != "" {
+		i := wildcardIndex(rest)
+		if i != 0 {
+			s := rest
+			if i > 0 {
+				s = rest[:i]
+			}
+			n = n.insertStatic(s)
+			rest = rest[len(s):]
 			continue
 		}
-		children := t.children
-		isMatch := false
-		for _, node := range children {
-			if node.name == name {
-				t = node
-				isMatch = true
-				break
-			}
+		seg := rest
+		if end := strings.IndexByte(rest, '/'); end >= 0 {
+			seg = rest[:end]
 		}
-		if !isMatch {
-			isEnd := false
-			if index == len(strs)-1 {
-				isEnd = true
-			}
-			node := &treeNode{
-				name:     name,
-				children: make([]*treeNode, 0),
-				isEnd:    isEnd,
+		rest = rest[len(seg):]
+		n = n.insertWild(seg)
+	}
+	n.isEnd = true
+	n.routerName = path
+}
+
+// wildcardIndex 返回第一个参数段的起始位置，不存在返回 -1
+func wildcardIndex(path string) int {
+	for i := 0; i < len(path); i++ {
+		if i > 0 && path[i-1] != '/' {
+			continue
+		}
+		end := strings.IndexByte(path[i:], '/')
+		if end < 0 {
+			end = len(path) - i
+		}
+		if isWildSegment(path[i : i+end]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isWildSegment(seg string) bool {
+	return len(seg) > 1 && seg[0] == ':' || seg == "*" || seg == "**"
+}
+
+func (t *treeNode) insertStatic(path string) *treeNode {
+	n := t
+	for path != "" {
+		idx := strings.IndexByte(n.indices, path[0])
+		if idx < 0 {
+			child := &treeNode{path: path, nType: static}
+			n.indices += path[:1]
+			n.children = append(n.children, child)
+			return child
+		}
+		child := n.children[idx]
+		l := longestCommonPrefix(path, child.path)
+		if l < len(child.path) {
+			split := *child
+			split.path = child.path[l:]
+			*child = treeNode{
+				path:     child.path[:l],
+				nType:    static,
+				indices:  split.path[:1],
+				children: []*treeNode{&split},
 			}
-			children = append(t.children, node)
-			t.children = children
-			t = node
 		}
+		path = path[l:]
+		n = child
+	}
+	return n
+}
+
+func (t *treeNode) insertWild(seg string) *treeNode {
+	switch seg {
+	case "**":
+		if t.catchAllChild == nil {
+			t.catchAllChild = &treeNode{path: seg, nType: catchAll, key: seg}
+		}
+		return t.catchAllChild
+	case "*":
+		if t.wildChild == nil {
+			t.wildChild = &treeNode{path: seg, nType: wildcard, key: seg}
+		}
+		return t.wildChild
+	}
+	for _, child := range t.paramChildren {
+		if child.path == seg {
+			return child
+		}
+	}
+	key, constraint := mustParseParamSegment(seg)
+	child := &treeNode{path: seg, nType: param, key: key, constraint: constraint}
+	// 带约束的参数优先匹配
+	i := len(t.paramChildren)
+	if constraint != nil {
+		for i = 0; i < len(t.paramChildren) && t.paramChildren[i].constraint != nil; i++ {
+		}
+	}
+	t.paramChildren = append(t.paramChildren, nil)
+	copy(t.paramChildren[i+1:], t.paramChildren[i:])
+	t.paramChildren[i] = child
+	return child
+}
+
+func longestCommonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
 	}
-	t = root
+	return i
 }
 
 // Get path: /user/get/1
-func (t *treeNode) Get(path string) *treeNode {
-	strs := strings.Split(path, "/")
-	routerName := ""
-	for index, name := range strs {
-		if index == 0 {
-			continue
+// 返回匹配到的路由模板，如 /user/get/:id。
+// 匹配到的 :name、* 参数以及 ** 剩余路径会追加到 params 中，params 容量足够时不产生内存分配。
+// 查找过程只读，不修改树上的任何状态，可被多个请求并发调用。
+func (t *treeNode) Get(path string, params *Params) (string, bool) {
+	node := t.search(path, params)
+	if node == nil {
+		return "", false
+	}
+	return node.routerName, true
+}
+
+func (t *treeNode) search(path string, params *Params) *treeNode {
+	if path == "" {
+		if t.isEnd {
+			return t
 		}
-		children := t.children
-		isMatch := false
-		for _, node := range children {
-			if node.name == name || node.name == "*" || strings.Contains(node.name, ":") {
-				isMatch = true
-				t = node
-				routerName += "/" + node.name
-				node.routerName = routerName
-				if index == len(strs)-1 {
+	} else {
+		if idx := strings.IndexByte(t.indices, path[0]); idx >= 0 {
+			child := t.children[idx]
+			if strings.HasPrefix(path, child.path) {
+				if node := child.search(path[len(child.path):], params); node != nil {
 					return node
 				}
-				break
 			}
 		}
-		if !isMatch {
-			for _, node := range children {
-				if node.name == "**" {
-					routerName += "/" + node.name
-					node.routerName = routerName
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			seg := path[:end]
+			for _, child := range t.paramChildren {
+				if node := child.searchSegment(seg, path[end:], params); node != nil {
+					return node
+				}
+			}
+			if t.wildChild != nil {
+				if node := t.wildChild.searchSegment(seg, path[end:], params); node != nil {
 					return node
 				}
 			}
-			return nil
 		}
 	}
+	if t.catchAllChild != nil {
+		pushParam(params, t.catchAllChild.key, path)
+		return t.catchAllChild
+	}
+	return nil
+}
+
+func (t *treeNode) searchSegment(seg, rest string, params *Params) *treeNode {
+	if t.constraint != nil && !t.constraint.match(seg) {
+		return nil
+	}
+	if params == nil {
+		return t.search(rest, nil)
+	}
+	l := len(*params)
+	*params = append(*params, Param{Key: t.key, Value: seg})
+	if node := t.search(rest, params); node != nil {
+		return node
+	}
+	*params = (*params)[:l]
 	return nil
 }
+
+func pushParam(params *Params, key, value string) {
+	if params != nil {
+		*params = append(*params, Param{Key: key, Value: value})
+	}
+}
+
+// countParams 统计路由中参数段的数量，用于预分配 Context.Params
+func countParams(path string) int {
+	n := 0
+	for _, seg := range strings.Split(path, "/") {
+		if isWildSegment(seg) {
+			n++
+		}
+	}
+	return n
+}
+
+// findCaseInsensitive 忽略大小写查找路由，返回按注册时大小写修正后的路径
+func (t *treeNode) findCaseInsensitive(path string) (string, bool) {
+	buf, ok := t.searchCaseInsensitive(path, make([]byte, 0, len(path)))
+	return string(buf), ok
+}
+
+func (t *treeNode) searchCaseInsensitive(path string, buf []byte) ([]byte, bool) {
+	if path == "" {
+		if t.isEnd {
+			return buf, true
+		}
+	} else {
+		for _, child := range t.children {
+			if len(path) >= len(child.path) && equalFoldASCII(path[:len(child.path)], child.path) {
+				if out, ok := child.searchCaseInsensitive(path[len(child.path):], append(buf, child.path...)); ok {
+					return out, true
+				}
+			}
+		}
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			for _, child := range t.paramChildren {
+				if child.constraint != nil && !child.constraint.match(path[:end]) {
+					continue
+				}
+				if out, ok := child.searchCaseInsensitive(path[end:], append(buf, path[:end]...)); ok {
+					return out, true
+				}
+			}
+			if t.wildChild != nil {
+				if out, ok := t.wildChild.searchCaseInsensitive(path[end:], append(buf, path[:end]...)); ok {
+					return out, true
+				}
+			}
+		}
+	}
+	if t.catchAllChild != nil {
+		return append(buf, path...), true
+	}
+	return nil, false
+}
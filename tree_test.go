@@ -85,3 +85,228 @@ func (t *Trie) Get(path string) *Trie {
 	}
 	return nil
 }
+
+func TestTreeNodePriority(t *testing.T) {
+	orders := [][]string{
+		{"/user/:id", "/user/new", "/user/*", "/user/**"},
+		{"/user/**", "/user/*", "/user/new", "/user/:id"},
+	}
+	for _, routes := range orders {
+		root := &treeNode{}
+		for _, r := range routes {
+			root.Put(r)
+		}
+		tests := []struct {
+			path  string
+			route string
+		}{
+			{"/user/new", "/user/new"},
+			{"/user/ne", "/user/:id"},
+			{"/user/newer", "/user/:id"},
+			{"/user/1/2", "/user/**"},
+			{"/user/", "/user/**"},
+		}
+		for _, tt := range tests {
+			var ps Params
+			route, ok := root.Get(tt.path, &ps)
+			if !ok || route != tt.route {
+				t.Errorf("routes %v: Get(%q) = %s, want %s", routes, tt.path, route, tt.route)
+			}
+		}
+	}
+}
+
+func TestTreeNodeBacktracking(t *testing.T) {
+	root := &treeNode{}
+	root.Put("/a/b/d")
+	root.Put("/a/:x/c")
+	root.Put("/a/*/c/e")
+	root.Put("/files/**")
+
+	tests := []struct {
+		path   string
+		route  string
+		params Params
+	}{
+		{"/a/b/d", "/a/b/d", nil},
+		{"/a/b/c", "/a/:x/c", Params{{"x", "b"}}},
+		{"/a/b/c/e", "/a/*/c/e", Params{{"*", "b"}}},
+		{"/files/css/a.css", "/files/**", Params{{"**", "css/a.css"}}},
+	}
+	for _, tt := range tests {
+		var ps Params
+		route, ok := root.Get(tt.path, &ps)
+		if !ok || route != tt.route {
+			t.Fatalf("Get(%q) = %s, want %s", tt.path, route, tt.route)
+		}
+		if len(ps) != len(tt.params) {
+			t.Fatalf("Get(%q) params = %v, want %v", tt.path, ps, tt.params)
+		}
+		for i := range ps {
+			if ps[i] != tt.params[i] {
+				t.Fatalf("Get(%q) params = %v, want %v", tt.path, ps, tt.params)
+			}
+		}
+	}
+	for _, path := range []string{"/a", "/a/b", "/a/b/x", "/files", "/b"} {
+		if route, ok := root.Get(path, nil); ok {
+			t.Errorf("Get(%q) = %s, want no match", path, route)
+		}
+	}
+}
+
+var benchRoutes = []string{
+	"/user/get/:id",
+	"/user/create/*",
+	"/user/test/hello",
+	"/user/test/aaa",
+	"/user/:name/profile",
+	"/order/get/aaa",
+	"/order/list",
+	"/order/:id/items/:item",
+	"/static/**",
+}
+
+var benchPaths = []string{
+	"/user/get/1",
+	"/user/test/aaa",
+	"/order/42/items/7",
+	"/static/css/app.css",
+}
+
+func BenchmarkTreeNodeGet(b *testing.B) {
+	root := &treeNode{}
+	for _, r := range benchRoutes {
+		root.Put(r)
+	}
+	ps := make(Params, 0, 4)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range benchPaths {
+			ps = ps[:0]
+			root.Get(path, &ps)
+		}
+	}
+}
+
+func BenchmarkLegacyTreeNodeGet(b *testing.B) {
+	root := &legacyTreeNode{name: "/"}
+	for _, r := range benchRoutes {
+		root.Put(r)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range benchPaths {
+			root.Get(path)
+		}
+	}
+}
+
+// legacyTreeNode 旧版按 "/" 分段的路由树，仅用于基准对比
+type legacyTreeNode struct {
+	name       string
+	children   []*legacyTreeNode
+	routerName string
+	isEnd      bool
+}
+
+func (t *legacyTreeNode) Put(path string) {
+	strs := strings.Split(path, "/")
+	for index, name := range strs {
+		if index == 0 {
+			continue
+		}
+		isMatch := false
+		for _, node := range t.children {
+			if node.name == name {
+				t = node
+				isMatch = true
+				break
+			}
+		}
+		if !isMatch {
+			node := &legacyTreeNode{
+				name:  name,
+				isEnd: index == len(strs)-1,
+			}
+			t.children = append(t.children, node)
+			t = node
+		}
+	}
+}
+
+func (t *legacyTreeNode) Get(path string) *legacyTreeNode {
+	strs := strings.Split(path, "/")
+	routerName := ""
+	for index, name := range strs {
+		if index == 0 {
+			continue
+		}
+		isMatch := false
+		for _, node := range t.children {
+			if node.name == name || node.name == "*" || strings.Contains(node.name, ":") {
+				isMatch = true
+				t = node
+				routerName += "/" + node.name
+				node.routerName = routerName
+				if index == len(strs)-1 {
+					return node
+				}
+				break
+			}
+		}
+		if !isMatch {
+			for _, node := range t.children {
+				if node.name == "**" {
+					routerName += "/" + node.name
+					node.routerName = routerName
+					return node
+				}
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestTreeNodeConstraints(t *testing.T) {
+	root := &treeNode{}
+	root.Put("/user/:name")
+	root.Put("/user/:id<int>")
+	root.Put("/file/:name<[a-z0-9-]+>")
+	root.Put("/file/*")
+	root.Put("/at/:date<date>")
+	root.Put("/item/:id<uuid>/detail")
+
+	tests := []struct {
+		path  string
+		route string
+	}{
+		{"/user/42", "/user/:id<int>"},
+		{"/user/-42", "/user/:id<int>"},
+		{"/user/ly", "/user/:name"},
+		{"/file/a-b-1", "/file/:name<[a-z0-9-]+>"},
+		{"/file/A.txt", "/file/*"},
+		{"/at/2024-05-20", "/at/:date<date>"},
+		{"/item/6BA7B810-9DAD-11D1-80B4-00C04FD430C8/detail", "/item/:id<uuid>/detail"},
+	}
+	for _, tt := range tests {
+		var ps Params
+		route, ok := root.Get(tt.path, &ps)
+		if !ok || route != tt.route {
+			t.Errorf("Get(%q) = %s, want %s", tt.path, route, tt.route)
+		}
+	}
+	for _, path := range []string{"/at/2024-13-01", "/at/today", "/item/1/detail"} {
+		if route, ok := root.Get(path, nil); ok {
+			t.Errorf("Get(%q) = %s, want no match", path, route)
+		}
+	}
+	var ps Params
+	root.Get("/user/42", &ps)
+	if len(ps) != 1 || ps[0] != (Param{Key: "id", Value: "42"}) {
+		t.Errorf("params = %v, want [{id 42}]", ps)
+	}
+}
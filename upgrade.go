@@ -0,0 +1,200 @@
+package nxjgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvUpgradeReadyFd 新进程开始服务后向该文件描述符写入一个字节，通知旧进程退出
+const EnvUpgradeReadyFd = "NXJ_UPGRADE_READY_FD"
+
+// Upgrade 以相同的参数启动当前可执行文件的新进程，通过 LISTEN_FDS 将所有监听套接字交给新进程，
+// 新进程开始服务后当前进程停止接受连接，处理完已接受连接上的请求后优雅退出，期间不会拒绝或丢弃任何连接。
+// 新进程启动失败、退出或超过 UpgradeTimeout 未就绪时返回错误，当前进程继续服务。
+// 新进程就绪后立即返回，退出流程在后台执行，因此可以在处理函数中调用
+func (e *Engine) Upgrade() error {
+	if !e.upgrading.CompareAndSwap(false, true) {
+		return errors.New("nxjgo: upgrade already in progress")
+	}
+	defer e.upgrading.Store(false)
+
+	e.srvMu.Lock()
+	closing := e.closing
+	listeners := append([]net.Listener(nil), e.listeners...)
+	e.srvMu.Unlock()
+	if closing {
+		return http.ErrServerClosed
+	}
+	if len(listeners) == 0 {
+		return errors.New("nxjgo: no listener to hand over")
+	}
+
+	files := make([]*os.File, 0, len(listeners)+1)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	for _, l := range listeners {
+		fl, ok := l.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("nxjgo: listener %s cannot be passed to a new process", l.Addr())
+		}
+		f, err := fl.File()
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer ready.Close()
+	files = append(files, readyW)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(upgradeEnv(os.Environ()),
+		EnvListenFds+"="+strconv.Itoa(len(listeners)),
+		EnvUpgradeReadyFd+"="+strconv.Itoa(listenFdsStart+len(listeners)),
+	)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// 关闭本进程持有的写端，新进程退出时读取才会返回 EOF
+	_ = readyW.Close()
+	files = files[:len(files)-1]
+
+	readyCh := make(chan error, 1)
+	go func() {
+		_, err := ready.Read(make([]byte, 1))
+		readyCh <- err
+	}()
+	timer := time.NewTimer(e.UpgradeTimeout)
+	defer timer.Stop()
+	select {
+	case err = <-readyCh:
+		if err != nil {
+			err = fmt.Errorf("nxjgo: new process exited before ready: %w", err)
+		}
+	case <-timer.C:
+		err = errors.New("nxjgo: timed out waiting for new process to be ready")
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		go cmd.Wait()
+		return err
+	}
+	go cmd.Wait()
+
+	debugPrint("New process %d is ready, shutting down", cmd.Process.Pid)
+	go e.handOver()
+	return nil
+}
+
+// handOver 新进程就绪后停止接受连接，已接受的连接处理完请求后再退出。
+// http.Server.Shutdown 开始后读到的请求会被直接丢弃，因此先关闭监听器并等待已接受的连接，最后才调用 Shutdown；
+// 整个过程不超过 ShutdownTimeout
+func (e *Engine) handOver() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.ShutdownTimeout)
+	defer cancel()
+	e.srvMu.Lock()
+	e.closing = true
+	done := make(chan struct{})
+	e.handoverDone = done
+	servers, listeners := e.servers, e.listeners
+	e.srvMu.Unlock()
+	defer close(done)
+
+	for _, srv := range servers {
+		// 处理完当前请求后关闭连接，空闲的长连接立即关闭，客户端会在新连接上重试
+		srv.SetKeepAlivesEnabled(false)
+	}
+	for _, l := range listeners {
+		// 套接字文件已由新进程使用，关闭时不能删除
+		if ul, ok := l.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+		_ = l.Close()
+	}
+	// Serve 返回后不会再有新接受的连接
+	e.serving.Wait()
+	if err := e.waitConns(ctx); err != nil {
+		e.logError("upgrade: " + err.Error())
+	}
+	if err := e.Shutdown(ctx); err != nil {
+		e.logError("upgrade: " + err.Error())
+	}
+}
+
+// upgradeEnv 去掉从上一次升级或 socket activation 继承的环境变量
+func upgradeEnv(environ []string) []string {
+	env := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		switch key {
+		case EnvListenFds, EnvListenPid, "LISTEN_FDNAMES", EnvUpgradeReadyFd:
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// notifyUpgradeReady 由 Upgrade 启动的新进程开始服务后通知旧进程
+func notifyUpgradeReady() {
+	v := os.Getenv(EnvUpgradeReadyFd)
+	if v == "" {
+		return
+	}
+	_ = os.Unsetenv(EnvUpgradeReadyFd)
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return
+	}
+	if f := os.NewFile(uintptr(fd), "upgrade-ready"); f != nil {
+		_, _ = f.Write([]byte{1})
+		_ = f.Close()
+	}
+}
+
+// upgradeOnSignal 收到 upgradeSignals 时执行 Upgrade，返回的函数用于停止监听信号
+func (e *Engine) upgradeOnSignal() (stop func()) {
+	if len(upgradeSignals) == 0 {
+		return func() {}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, upgradeSignals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ch:
+				if err := e.Upgrade(); err != nil {
+					e.logError("upgrade: " + err.Error())
+				}
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
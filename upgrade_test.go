@@ -0,0 +1,140 @@
+//go:build !windows
+
+package nxjgo
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestUpgrade 以子进程运行自身作为服务，先通过 Upgrade 再通过 SIGUSR2 各升级一次，
+// 期间持续发起请求，确认没有请求失败且处理中的请求正常完成
+func TestUpgrade(t *testing.T) {
+	if addr := os.Getenv("NXJ_TEST_UPGRADE_ADDR"); addr != "" {
+		runUpgradeServer(addr)
+		return
+	}
+	addr := freeAddr(t)
+	base := "http://" + addr
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(path string) (string, error) {
+		resp, err := client.Get(base + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestUpgrade$")
+	cmd.Env = append(os.Environ(), "NXJ_TEST_UPGRADE_ADDR="+addr)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = get("/stop") })
+	waitServing(t, base+"/pid")
+	first, _ := get("/pid")
+
+	var failed atomic.Int64
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, err := get("/pid"); err != nil {
+				failed.Add(1)
+				t.Log(err)
+			}
+		}
+	}()
+
+	slow := make(chan string, 1)
+	go func() {
+		body, err := get("/slow")
+		if err != nil {
+			body = err.Error()
+		}
+		slow <- body
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if body, err := get("/upgrade"); err != nil || body != "ok" {
+		t.Fatalf("upgrade = %q, %v", body, err)
+	}
+	if body := <-slow; body != "slow "+first {
+		t.Errorf("in-flight request = %q, want %q", body, "slow "+first)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("old process exited with %v", err)
+	}
+	second, _ := get("/pid")
+	if second == first || second == "" {
+		t.Fatalf("pid after upgrade = %q, before %q", second, first)
+	}
+
+	pid, _ := strconv.Atoi(second)
+	if err := syscall.Kill(pid, syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	third := second
+	for i := 0; i < 500 && (third == second || third == ""); i++ {
+		third, _ = get("/pid")
+		time.Sleep(10 * time.Millisecond)
+	}
+	if third == second {
+		t.Fatal("SIGUSR2 did not upgrade the server")
+	}
+	// 旧进程退出
+	for i := 0; i < 500 && syscall.Kill(pid, 0) == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if syscall.Kill(pid, 0) == nil {
+		t.Errorf("process %d still running after SIGUSR2 upgrade", pid)
+	}
+
+	close(stop)
+	<-done
+	if n := failed.Load(); n > 0 {
+		t.Errorf("%d requests failed during upgrades", n)
+	}
+}
+
+func runUpgradeServer(addr string) {
+	SetMode(ReleaseMode)
+	pid := strconv.Itoa(os.Getpid())
+	e := New()
+	e.UpgradeOnSignal = true
+	g := e.Group("")
+	g.Get("/pid", func(ctx *Context) { _ = ctx.String(http.StatusOK, pid) })
+	g.Get("/slow", func(ctx *Context) {
+		time.Sleep(300 * time.Millisecond)
+		_ = ctx.String(http.StatusOK, "slow "+pid)
+	})
+	g.Get("/upgrade", func(ctx *Context) {
+		if err := e.Upgrade(); err != nil {
+			_ = ctx.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		_ = ctx.String(http.StatusOK, "ok")
+	})
+	g.Get("/stop", func(ctx *Context) {
+		go e.Shutdown(context.Background())
+	})
+	if err := e.RunWithContext(context.Background(), addr); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package nxjgo
+
+import "os"
+
+// Windows 没有 SIGUSR2，只能通过 Engine.Upgrade 触发升级
+var upgradeSignals []os.Signal
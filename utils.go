@@ -1,6 +1,8 @@
 package nxjgo
 
 import (
+	"reflect"
+	"runtime"
 	"strings"
 	"unicode"
 	"unsafe"
@@ -31,3 +33,7 @@ func StringToBytes(s string) []byte {
 		}{s, len(s)},
 	))
 }
+
+func nameOfFunction(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
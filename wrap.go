@@ -0,0 +1,83 @@
+package nxjgo
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// WrapF 将 http.HandlerFunc 转换为 HandlerFunc
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return WrapH(f)
+}
+
+// WrapH 将 http.Handler 转换为 HandlerFunc
+func WrapH(h http.Handler) HandlerFunc {
+	return func(ctx *Context) {
+		h.ServeHTTP(ctx.W, ctx.R)
+	}
+}
+
+// WrapMiddleware 将 func(http.Handler) http.Handler 形式的标准中间件转换为 MiddlewareFunc。
+// 标准中间件替换的 ResponseWriter 和 *http.Request 会传递给后续的处理函数，返回后恢复为原来的值。
+// 替换请求的 context 时需要从原 context 派生，否则无法找回 Context，直接返回 500
+func WrapMiddleware(m func(http.Handler) http.Handler) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		h := m(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, ok := r.Context().Value(contextKey{}).(*Context)
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			ctx.W = w
+			ctx.R = r
+			next(ctx)
+		}))
+		return func(ctx *Context) {
+			// 标准中间件替换的 W、R 只对内层有效，返回后恢复，外层中间件仍看到原来的请求
+			w, r := ctx.W, ctx.R
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, ctx)))
+			ctx.W, ctx.R = w, r
+		}
+	}
+}
+
+// Mount 将 http.Handler(包括另一个 Engine)挂载到 prefix 下，转发前去掉路由组前缀和 prefix
+func (r *routerGroup) Mount(prefix string, h http.Handler, middlewareFunc ...MiddlewareFunc) {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix != "" && prefix[0] != '/' {
+		prefix = "/" + prefix
+	}
+	handler := mountHandler(r.prefix+prefix, h)
+	if prefix != "" {
+		r.Any(prefix, handler, middlewareFunc...)
+	}
+	r.Any(prefix+"/**", handler, middlewareFunc...)
+}
+
+func mountHandler(strip string, h http.Handler) HandlerFunc {
+	return func(ctx *Context) {
+		r := new(http.Request)
+		*r = *ctx.R
+		u := *ctx.R.URL
+		r.URL = &u
+		u.Path = strings.TrimPrefix(u.Path, strip)
+		if u.Path == "" {
+			u.Path = "/"
+		}
+		if u.RawPath != "" {
+			rawPath := strings.TrimPrefix(u.RawPath, strip)
+			switch {
+			case rawPath == u.RawPath:
+				u.RawPath = ""
+			case rawPath == "":
+				u.RawPath = "/"
+			default:
+				u.RawPath = rawPath
+			}
+		}
+		h.ServeHTTP(ctx.W, r)
+	}
+}
@@ -0,0 +1,111 @@
+package nxjgo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	sub := New()
+	sub.Group("").Get("/users/:id", func(ctx *Context) {
+		_ = ctx.String(http.StatusOK, "sub %s %s", ctx.R.URL.Path, ctx.Param("id"))
+	})
+
+	e := New()
+	var status int
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			next(ctx)
+			status = ctx.StatusCode
+		}
+	})
+	api := e.Group("api")
+	api.Mount("/v2", sub)
+	api.Mount("raw", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	api.Get("/f", WrapF(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("wrapped"))
+	}))
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/api/v2/users/7", http.StatusOK, "sub /users/7 7"},
+		{"/api/raw", http.StatusAccepted, "/"},
+		{"/api/raw/a/b", http.StatusAccepted, "/a/b"},
+		{"/api/f", http.StatusOK, "wrapped"},
+		{"/api/v2/none", http.StatusNotFound, "/api/v2/none not found."},
+	}
+	for _, tt := range tests {
+		status = 0
+		w := performRequest(e, http.MethodGet, tt.path)
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+		if status != tt.code {
+			t.Errorf("GET %s ctx.StatusCode = %d, want %d", tt.path, status, tt.code)
+		}
+	}
+}
+
+func TestWrapMiddleware(t *testing.T) {
+	std := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Std", "1")
+			r.Header.Set("X-Seen", "std")
+			next.ServeHTTP(w, r)
+		})
+	}
+	e := New()
+	g := e.Group("")
+	g.Use(WrapMiddleware(std))
+	g.Get("/", func(ctx *Context) { _ = ctx.String(http.StatusOK, ctx.R.Header.Get("X-Seen")) })
+
+	w := performRequest(e, http.MethodGet, "/")
+	if w.Header().Get("X-Std") != "1" || w.Body.String() != "std" {
+		t.Errorf("WrapMiddleware = %q %q", w.Header().Get("X-Std"), w.Body.String())
+	}
+}
+
+func TestWrapMiddlewareRestoresRequest(t *testing.T) {
+	var after string
+	e := New()
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			next(ctx)
+			after = ctx.R.URL.Path
+		}
+	})
+	g := e.Group("")
+	g.Use(WrapMiddleware(func(next http.Handler) http.Handler {
+		return http.StripPrefix("/v", next)
+	}))
+	g.Get("/v/x", func(ctx *Context) { _ = ctx.String(http.StatusOK, ctx.R.URL.Path) })
+
+	w := performRequest(e, http.MethodGet, "/v/x")
+	if w.Body.String() != "/x" || after != "/v/x" {
+		t.Errorf("inner path = %q, outer path after next = %q", w.Body.String(), after)
+	}
+}
+
+func TestWrapMiddlewareReplacedContext(t *testing.T) {
+	std := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.Background()))
+		})
+	}
+	e := New()
+	g := e.Group("")
+	g.Use(WrapMiddleware(std))
+	g.Get("/", func(ctx *Context) { _ = ctx.String(http.StatusOK, "ok") })
+
+	w := performRequest(e, http.MethodGet, "/")
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}